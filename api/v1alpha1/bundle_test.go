@@ -0,0 +1,318 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nuagenetworks/nuage-network-operator/version"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	bundleCSV = "../../bundle/manifests/nuage-network-operator.clusterserviceversion.yaml"
+	bundleCRD = "../../bundle/manifests/operator.nuage.io_nuagecniconfigs.yaml"
+	configCRD = "../../config/crd/bases/operator.nuage.io_nuagecniconfigs.yaml"
+	roleYAML  = "../../config/rbac/role.yaml"
+)
+
+// renderedBindata are the golden renderings of the bindata, kept in sync by
+// the render tests
+var renderedBindata = []string{
+	"../../controllers/render/testdata/bindata-k8s.golden",
+	"../../controllers/render/testdata/bindata-ose.golden",
+}
+
+// csv is the part of the cluster service version checked against the api
+type csv struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Version                   string `json:"version"`
+		Replaces                  string `json:"replaces"`
+		CustomResourceDefinitions struct {
+			Owned []struct {
+				Name              string       `json:"name"`
+				Kind              string       `json:"kind"`
+				Version           string       `json:"version"`
+				SpecDescriptors   []descriptor `json:"specDescriptors"`
+				StatusDescriptors []descriptor `json:"statusDescriptors"`
+			} `json:"owned"`
+		} `json:"customresourcedefinitions"`
+		Install struct {
+			Spec struct {
+				ClusterPermissions []permission `json:"clusterPermissions"`
+				Permissions        []permission `json:"permissions"`
+				Deployments        []struct {
+					Name string                `json:"name"`
+					Spec appsv1.DeploymentSpec `json:"spec"`
+				} `json:"deployments"`
+			} `json:"spec"`
+		} `json:"install"`
+	} `json:"spec"`
+}
+
+type descriptor struct {
+	Path        string `json:"path"`
+	DisplayName string `json:"displayName"`
+}
+
+type permission struct {
+	ServiceAccountName string              `json:"serviceAccountName"`
+	Rules              []rbacv1.PolicyRule `json:"rules"`
+}
+
+// fieldPaths lists the json paths of the fields of t. Structs of this package
+// are descended into, any other type is a single field
+func fieldPaths(t reflect.Type, prefix string) []string {
+	paths := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		path := prefix + strings.Split(f.Tag.Get("json"), ",")[0]
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft.PkgPath() == t.PkgPath() {
+			paths = append(paths, fieldPaths(ft, path+".")...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func descriptorPaths(descriptors []descriptor) []string {
+	paths := []string{}
+	for _, d := range descriptors {
+		paths = append(paths, d.Path)
+	}
+	return paths
+}
+
+func readYAML(g *GomegaWithT, file string, o interface{}) {
+	b, err := ioutil.ReadFile(file)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(yaml.UnmarshalStrict(b, o)).To(Succeed(), file)
+}
+
+// semver parses a major.minor.patch version
+func semver(g *GomegaWithT, v string) [3]int {
+	parts := strings.Split(v, ".")
+	g.Expect(parts).To(HaveLen(3), v)
+	s := [3]int{}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		g.Expect(err).NotTo(HaveOccurred(), v)
+		s[i] = n
+	}
+	return s
+}
+
+func olderThan(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+func loadCSV(g *GomegaWithT) *csv {
+	b, err := ioutil.ReadFile(bundleCSV)
+	g.Expect(err).NotTo(HaveOccurred())
+	c := &csv{}
+	g.Expect(yaml.Unmarshal(b, c)).To(Succeed())
+	g.Expect(c.Spec.CustomResourceDefinitions.Owned).To(HaveLen(1))
+	return c
+}
+
+func TestBundleDescriptors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	owned := loadCSV(g).Spec.CustomResourceDefinitions.Owned[0]
+	g.Expect(owned.Name).To(Equal("nuagecniconfigs." + GroupVersion.Group))
+	g.Expect(owned.Kind).To(Equal(reflect.TypeOf(NuageCNIConfig{}).Name()))
+	g.Expect(owned.Version).To(Equal(GroupVersion.Version))
+
+	//every field has exactly one descriptor and every descriptor a field
+	g.Expect(descriptorPaths(owned.SpecDescriptors)).To(ConsistOf(fieldPaths(reflect.TypeOf(NuageCNIConfigSpec{}), "")))
+	g.Expect(descriptorPaths(owned.StatusDescriptors)).To(ConsistOf(fieldPaths(reflect.TypeOf(NuageCNIConfigStatus{}), "")))
+	for _, d := range append(owned.SpecDescriptors, owned.StatusDescriptors...) {
+		g.Expect(d.DisplayName).NotTo(BeEmpty(), d.Path)
+	}
+}
+
+func TestBundleExample(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	examples := []NuageCNIConfig{}
+	d := json.NewDecoder(strings.NewReader(loadCSV(g).Metadata.Annotations["alm-examples"]))
+	d.DisallowUnknownFields()
+	g.Expect(d.Decode(&examples)).To(Succeed())
+	g.Expect(examples).To(HaveLen(1))
+
+	example := NuageCNIConfig{}
+	readYAML(g, "../../example-configs/nuageconfig.yaml", &example)
+	g.Expect(examples[0]).To(Equal(example))
+
+	sample := NuageCNIConfig{}
+	readYAML(g, "../../config/samples/operator_v1alpha1_nuagecniconfig.yaml", &sample)
+	g.Expect(sample).To(Equal(example))
+}
+
+func TestBundleCRD(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	bundle, err := ioutil.ReadFile(bundleCRD)
+	g.Expect(err).NotTo(HaveOccurred())
+	config, err := ioutil.ReadFile(configCRD)
+	g.Expect(err).NotTo(HaveOccurred())
+	//the config crd only differs by its license header
+	g.Expect(bytes.HasSuffix(config, bundle)).To(BeTrue(), "%s is not in sync with %s", bundleCRD, configCRD)
+}
+
+// allows tells if one of rules grants verb on resource of group
+func allows(rules []rbacv1.PolicyRule, group, resource, verb string) bool {
+	has := func(values []string, v string) bool {
+		for _, value := range values {
+			if value == v || value == rbacv1.ResourceAll {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range rules {
+		if has(r.APIGroups, group) && has(r.Resources, resource) && has(r.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// readObjects reads the objects of a multi document yaml file
+func readObjects(g *GomegaWithT, file string) []map[string]interface{} {
+	b, err := ioutil.ReadFile(file)
+	g.Expect(err).NotTo(HaveOccurred())
+	objs := []map[string]interface{}{}
+	for _, doc := range strings.Split(string(b), "---\n") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		obj := map[string]interface{}{}
+		g.Expect(yaml.Unmarshal([]byte(doc), &obj)).To(Succeed(), file)
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+func TestBundleRBAC(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	install := loadCSV(g).Spec.Install.Spec
+	role := &rbacv1.ClusterRole{}
+	readYAML(g, roleYAML, role)
+	g.Expect(install.ClusterPermissions).To(HaveLen(1))
+	g.Expect(install.ClusterPermissions[0].Rules).To(Equal(role.Rules))
+
+	for _, file := range renderedBindata {
+		for _, obj := range readObjects(g, file) {
+			//the rendered objects are read, created, updated and deleted
+			group := ""
+			if gv := strings.Split(obj["apiVersion"].(string), "/"); len(gv) == 2 {
+				group = gv[0]
+			}
+			kind := obj["kind"].(string)
+			resource := strings.ToLower(kind) + "s"
+			for _, verb := range []string{"get", "create", "update", "delete"} {
+				g.Expect(allows(role.Rules, group, resource, verb)).To(BeTrue(), "%s: %s %s not allowed", file, verb, kind)
+			}
+			if kind != "ClusterRole" {
+				continue
+			}
+			//the operator cannot grant permissions it does not hold
+			data, err := yaml.Marshal(obj)
+			g.Expect(err).NotTo(HaveOccurred())
+			granted := &rbacv1.ClusterRole{}
+			g.Expect(yaml.Unmarshal(data, granted)).To(Succeed())
+			for _, r := range granted.Rules {
+				for _, apiGroup := range r.APIGroups {
+					for _, resource := range r.Resources {
+						for _, verb := range r.Verbs {
+							g.Expect(allows(role.Rules, apiGroup, resource, verb)).To(BeTrue(), "%s: %s %s/%s granted by %s not allowed", file, verb, apiGroup, resource, granted.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	leaderElection := &rbacv1.Role{}
+	readYAML(g, "../../config/rbac/leader_election_role.yaml", leaderElection)
+	g.Expect(install.Permissions).To(HaveLen(1))
+	g.Expect(install.Permissions[0].Rules).To(Equal(leaderElection.Rules))
+}
+
+func TestBundleDeployment(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deployments := loadCSV(g).Spec.Install.Spec.Deployments
+	g.Expect(deployments).To(HaveLen(1))
+	bundle := deployments[0].Spec.Template.Spec
+	manager := &appsv1.Deployment{}
+	readYAML(g, "../../config/manager/manager.yaml", manager)
+	config := manager.Spec.Template.Spec
+
+	//the volumes of the operator are generated from config/manager
+	g.Expect(bundle.Volumes).To(Equal(config.Volumes))
+	g.Expect(bundle.Containers).To(HaveLen(1))
+	g.Expect(config.Containers).To(HaveLen(1))
+	g.Expect(bundle.Containers[0].VolumeMounts).To(Equal(config.Containers[0].VolumeMounts))
+
+	bundleCA := &corev1.ConfigMap{}
+	readYAML(g, "../../bundle/manifests/nuage-network-operator-trusted-ca_v1_configmap.yaml", bundleCA)
+	configCA := &corev1.ConfigMap{}
+	readYAML(g, "../../config/manager/trusted_ca.yaml", configCA)
+	g.Expect(bundleCA.Name).To(Equal(configCA.Name))
+	g.Expect(bundleCA.Labels).To(Equal(configCA.Labels))
+	g.Expect(config.Volumes).To(HaveLen(1))
+	g.Expect(config.Volumes[0].ConfigMap.Name).To(Equal(configCA.Name))
+}
+
+func TestBundleUpgradeGraph(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := loadCSV(g)
+	g.Expect(c.Spec.Version).To(Equal(version.Version))
+	g.Expect(c.Metadata.Name).To(Equal("nuage-network-operator.v" + version.Version))
+
+	var min, max string
+	_, err := fmt.Sscanf(c.Metadata.Annotations["olm.skipRange"], ">=%s <%s", &min, &max)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(max).To(Equal(version.Version))
+	g.Expect(olderThan(semver(g, min), semver(g, max))).To(BeTrue())
+
+	//replaces is only set once a previous bundle was published
+	if c.Spec.Replaces == "" {
+		return
+	}
+	g.Expect(c.Spec.Replaces).To(HavePrefix("nuage-network-operator.v"))
+	replaces := strings.TrimPrefix(c.Spec.Replaces, "nuage-network-operator.v")
+	g.Expect(olderThan(semver(g, replaces), semver(g, version.Version))).To(BeTrue())
+	g.Expect(olderThan(semver(g, replaces), semver(g, min))).To(BeFalse())
+}
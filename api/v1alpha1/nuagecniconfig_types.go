@@ -23,7 +23,9 @@ package v1alpha1
 import (
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -42,59 +44,134 @@ type ReleaseConfigDefinition struct {
 	InfraTag string `json:"infraTag"`
 }
 
+// PlacementDefinition holds the scheduling settings of the pods of a component
+type PlacementDefinition struct {
+	NodeSelector      map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations       []corev1.Toleration `json:"tolerations,omitempty"`
+	Affinity          *corev1.Affinity    `json:"affinity,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty"`
+}
+
 // MonitorConfigDefinition holds user specified config for monitor
 type MonitorConfigDefinition struct {
 	// +kubebuilder:validation:MinLength=1
-	VSDAddress string `json:"vsdAddress"`
+	VSDAddress string `json:"vsdAddress,omitempty"`
 	// +kubebuilder:validation:Minimum=0
-	VSDPort                int      `json:"vsdPort"`
-	VSDMetadata            Metadata `json:"vsdMetadata"`
-	VSDFlags               Flags    `json:"vsdFlags"`
-	RestServerAddress      string   `json:"restServerAddress,omitempty"`
-	RestServerPort         int      `json:"restServerPort,omitempty"`
-	ServiceAccountName     string   `json:"ServiceAccountName,omitempty"`
-	ClusterRoleName        string   `json:"ClusterRoleName,omitempty"`
-	ClusterRoleBindingName string   `json:"ClusterRoleBindingName,omitempty"`
-	MasterNodeSelector     string   `json:"MasterNodeSelector,omitempty"`
+	VSDPort                int                         `json:"vsdPort,omitempty"`
+	VSDEndpoints           []VSDEndpoint               `json:"vsdEndpoints,omitempty"`
+	VSDMetadata            Metadata                    `json:"vsdMetadata"`
+	VSDFlags               Flags                       `json:"vsdFlags"`
+	VSDCA                  VSDCADefinition             `json:"vsdCA,omitempty"`
+	RestServerAddress      string                      `json:"restServerAddress,omitempty"`
+	RestServerPort         int                         `json:"restServerPort,omitempty"`
+	ServiceAccountName     string                      `json:"ServiceAccountName,omitempty"`
+	ClusterRoleName        string                      `json:"ClusterRoleName,omitempty"`
+	ClusterRoleBindingName string                      `json:"ClusterRoleBindingName,omitempty"`
+	MasterNodeSelector     string                      `json:"MasterNodeSelector,omitempty"`
+	ControlPlaneSelector   string                      `json:"controlPlaneSelector,omitempty"`
+	VSPVersion             string                      `json:"vspVersion,omitempty"`
+	LogLevel               int                         `json:"logLevel,omitempty"`
+	LogDir                 string                      `json:"logDir,omitempty"`
+	Etcd                   EtcdConfigDefinition        `json:"etcd,omitempty"`
+	Service                MonitorServiceDefinition    `json:"service,omitempty"`
+	Placement              PlacementDefinition         `json:"placement,omitempty"`
+	Resources              corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// VSDEndpoint is the address and port of one VSD of a VSD cluster. The
+// monitor is configured with the first endpoint the operator found healthy.
+// The operator checks the endpoints every 5 minutes, a switch to another
+// endpoint rolls the monitor pods, so failing over can take that long
+type VSDEndpoint struct {
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int `json:"port"`
+}
+
+// VSDCADefinition holds the ca the VSD certificate is verified with, either
+// inline or from the ca.crt key of a Secret or ConfigMap in the operator
+// namespace. Without it the system cas of the operator are used. The monitor
+// gets it in /etc/ssl/certs
+type VSDCADefinition struct {
+	CA           string                       `json:"ca,omitempty"`
+	SecretRef    *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+}
+
+// EtcdConfigDefinition holds the etcd client config of the monitor. The
+// certificate paths are under /etc/kubernetes/pki of the master nodes. A
+// ClientCertSecret in the operator namespace with ca.crt, tls.crt and
+// tls.key keys is used instead of them
+type EtcdConfigDefinition struct {
+	Endpoints        []string                     `json:"endpoints,omitempty"`
+	CA               string                       `json:"ca,omitempty"`
+	CertFile         string                       `json:"certFile,omitempty"`
+	KeyFile          string                       `json:"keyFile,omitempty"`
+	ClientCertSecret *corev1.LocalObjectReference `json:"clientCertSecret,omitempty"`
+}
+
+// MonitorServiceDefinition configures the service fronting the monitor rest
+// servers. When enabled and cniConfig.loadBalancerURL is not set, the cni
+// reaches the monitor through the cluster ip of the service
+type MonitorServiceDefinition struct {
+	Enabled bool `json:"enabled,omitempty"`
 }
 
 // InfraPodConfigDefenition holds user specified config for InfraPodConfigDefenition
+// Enterprise, domain and user default to the monitor VSD metadata and
+// pod network defaults to the cluster network
 type InfraPodConfigDefenition struct {
-	VSPEnterprise  string `json:"enterprise"`
-	VSPDomain      string `json:"domain"`
-	VSPUser        string `json:"user"`
-	VSPPodCIDR     string `json:"podNetwork"`
-	VRSPersonality string `json:"personality,omitempty"`
+	VSPEnterprise string `json:"enterprise,omitempty"`
+	VSPDomain     string `json:"domain,omitempty"`
+	VSPUser       string `json:"user,omitempty"`
+	VSPPodCIDR    string `json:"podNetwork,omitempty"`
+	// +kubebuilder:validation:Enum=vrs;vrs-g;avrs;avrs-g;vdf;evdf
+	VRSPersonality string                      `json:"personality,omitempty"`
+	Placement      PlacementDefinition         `json:"placement,omitempty"`
+	Resources      corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // VRSConfigDefinition holds user specified config for VRS
+// Controllers are ips or hostnames of the VSCs. Either controllers, where the
+// first one is active and the second one is standby, or explicit active and
+// standby lists of one controller each are expected
 type VRSConfigDefinition struct {
-	// +kubebuilder:validation:MinItems=1
-	Controllers []string `json:"controllers"`
+	// +kubebuilder:validation:MaxItems=2
+	Controllers []string `json:"controllers,omitempty"`
+	// +kubebuilder:validation:MaxItems=1
+	ActiveControllers []string `json:"active,omitempty"`
+	// +kubebuilder:validation:MaxItems=1
+	StandbyControllers []string `json:"standby,omitempty"`
 	// +kubebuilder:validation:MinLength=1
-	UnderlayUplink string `json:"underlayUplink"`
-	Platform       string `json:"platform,omitempty"`
+	UnderlayUplink string                      `json:"underlayUplink"`
+	Platform       string                      `json:"platform,omitempty"`
+	Placement      PlacementDefinition         `json:"placement,omitempty"`
+	Resources      corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // CNIConfigDefinition holds user specified config for CNI
 type CNIConfigDefinition struct {
-	// +kubebuilder:validation:MinLength=1
-	LoadBalancerURL         string `json:"loadBalancerURL"`
-	VRSEndpoint             string `json:"vrsEndpoint,omitempty"`
-	VRSBridge               string `json:"vrsBridge,omitempty"`
-	CNIVersion              string `json:"cniVersion,omitempty"`
-	LogLevel                string `json:"logLevel,omitempty"`
-	MTU                     int    `json:"mtu,omitempty"`
-	NuageSiteID             int    `json:"nuageSiteID,omitempty"`
-	LogFileSize             int    `json:"logFileSize,omitempty"`
-	MonitorInterval         int    `json:"monitorInterval,omitempty"`
-	PortResolveTimer        int    `json:"portResolveTimer,omitempty"`
-	VRSConnectionCheckTimer int    `json:"vrsConnectionCheckTimer,omitempty"`
-	StaleEntryTimeout       int    `json:"staleEntryTimeout,omitempty"`
-	ServiceAccountName      string `json:"serviceAccountName,omitempty"`
-	ClusterRoleName         string `json:"clusterRoleName,omitempty"`
-	ClusterRoleBindingName  string `json:"clusterRoleBindingName,omitempty"`
-	KubeConfig              string `json:"kubeConfig,omitempty"`
+	LoadBalancerURL         string                      `json:"loadBalancerURL,omitempty"`
+	VRSEndpoint             string                      `json:"vrsEndpoint,omitempty"`
+	VRSBridge               string                      `json:"vrsBridge,omitempty"`
+	CNIVersion              string                      `json:"cniVersion,omitempty"`
+	LogLevel                string                      `json:"logLevel,omitempty"`
+	MTU                     intstr.IntOrString          `json:"mtu,omitempty"`
+	NuageSiteID             int                         `json:"nuageSiteID,omitempty"`
+	LogFileSize             int                         `json:"logFileSize,omitempty"`
+	MonitorInterval         int                         `json:"monitorInterval,omitempty"`
+	PortResolveTimer        int                         `json:"portResolveTimer,omitempty"`
+	VRSConnectionCheckTimer int                         `json:"vrsConnectionCheckTimer,omitempty"`
+	StaleEntryTimeout       int                         `json:"staleEntryTimeout,omitempty"`
+	ServiceAccountName      string                      `json:"serviceAccountName,omitempty"`
+	ClusterRoleName         string                      `json:"clusterRoleName,omitempty"`
+	ClusterRoleBindingName  string                      `json:"clusterRoleBindingName,omitempty"`
+	KubeConfigPath          string                      `json:"kubeConfigPath,omitempty"`
+	APIServerURL            string                      `json:"apiServerURL,omitempty"`
+	Placement               PlacementDefinition         `json:"placement,omitempty"`
+	Resources               corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // Metadata holds the VSD metadata info
@@ -130,11 +207,64 @@ type RegistryConfig struct {
 }
 
 // PodNetworkConfigDefinition hold the pod network
-// to be only used for k8s
+// to be only used for k8s. Fields left empty are discovered
+// from the kubeadm-config configmap or the control plane static pods
 type PodNetworkConfigDefinition struct {
-	ClusterNetworkCIDR        string `json:"podNetwork"`
-	SubnetLength              uint32 `json:"subnetLength"`
-	ClusterServiceNetworkCIDR string `json:"ClusterServiceNetworkCIDR"`
+	ClusterNetworkCIDR        string `json:"podNetwork,omitempty"`
+	SubnetLength              uint32 `json:"subnetLength,omitempty"`
+	ClusterServiceNetworkCIDR string `json:"ClusterServiceNetworkCIDR,omitempty"`
+}
+
+// DeletionConfigDefinition controls the teardown of the nuage components
+// when the config is deleted. Pods still running after the timeout are
+// force deleted if forceDelete is set
+type DeletionConfigDefinition struct {
+	Timeout     *metav1.Duration `json:"timeout,omitempty"`
+	ForceDelete bool             `json:"forceDelete,omitempty"`
+}
+
+// DeletionPolicy decides what is left on the nodes when the config is deleted
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain leaves the cni and vrs files on the nodes
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyCleanupHost removes the cni and vrs files from the nodes
+	DeletionPolicyCleanupHost DeletionPolicy = "CleanupHost"
+)
+
+// ManagementState tells whether the operator manages the nuage components
+type ManagementState string
+
+const (
+	// ManagementStateManaged keeps the nuage components in sync with the config
+	ManagementStateManaged ManagementState = "Managed"
+	// ManagementStateUnmanaged leaves the nuage components as they are
+	ManagementStateUnmanaged ManagementState = "Unmanaged"
+	// ManagementStateRemoved tears down the nuage components
+	ManagementStateRemoved ManagementState = "Removed"
+)
+
+// RBACConfigDefinition holds the rbac settings of the nuage components.
+// LegacyWildcard grants the monitor and cni all permissions, as needed by
+// older images. The operator can only grant permissions it holds, so
+// LegacyWildcard needs the operator service account bound to all permissions
+// too, e.g. to cluster-admin. LegacyTokenEnv puts a non expiring token of the cni service
+// account in NUAGE_TOKEN for older cni images that do not read
+// NUAGE_TOKEN_FILE
+type RBACConfigDefinition struct {
+	LegacyWildcard bool `json:"legacyWildcard,omitempty"`
+	LegacyTokenEnv bool `json:"legacyTokenEnv,omitempty"`
+}
+
+// ProxyConfigDefinition holds the proxy of the monitor and infra pods on
+// kubernetes. On OpenShift the cluster Proxy object is used instead. The
+// TrustedCA config map in the operator namespace holds the ca-bundle.crt key
+type ProxyConfigDefinition struct {
+	HTTPProxy  string                       `json:"httpProxy,omitempty"`
+	HTTPSProxy string                       `json:"httpsProxy,omitempty"`
+	NoProxy    string                       `json:"noProxy,omitempty"`
+	TrustedCA  *corev1.LocalObjectReference `json:"trustedCA,omitempty"`
 }
 
 // NuageCNIConfigSpec defines the desired state of NuageCNIConfig
@@ -145,11 +275,84 @@ type NuageCNIConfigSpec struct {
 	MonitorConfig    MonitorConfigDefinition    `json:"monitorConfig"`
 	ReleaseConfig    ReleaseConfigDefinition    `json:"releaseConfig"`
 	PodNetworkConfig PodNetworkConfigDefinition `json:"podNetworkConfig"`
+	InfraConfig      InfraPodConfigDefenition   `json:"infraConfig,omitempty"`
+	DeletionConfig   DeletionConfigDefinition   `json:"deletionConfig,omitempty"`
+	// +kubebuilder:validation:Enum=Retain;CleanupHost
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// +kubebuilder:validation:Enum=Managed;Unmanaged;Removed
+	ManagementState ManagementState       `json:"managementState,omitempty"`
+	RBAC            RBACConfigDefinition  `json:"rbac,omitempty"`
+	Proxy           ProxyConfigDefinition `json:"proxy,omitempty"`
+}
+
+// ConditionType is the type of condition reported in the status
+type ConditionType string
+
+const (
+	// ConditionDegraded is true when the operator could not apply the config
+	ConditionDegraded ConditionType = "Degraded"
+	// ConditionVSDCertificateValid is true when the VSD certificate is trusted, not expired and matches its address
+	ConditionVSDCertificateValid ConditionType = "VSDCertificateValid"
+)
+
+// Condition holds the state of the operator for a given condition type
+type Condition struct {
+	Type               ConditionType          `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+}
+
+// DeletionPhase is the component being torn down
+type DeletionPhase string
+
+const (
+	// DeletionPhaseInfra removes the infra pods
+	DeletionPhaseInfra DeletionPhase = "infra"
+	// DeletionPhaseMonitor removes the monitor
+	DeletionPhaseMonitor DeletionPhase = "monitor"
+	// DeletionPhaseCNI removes the cni
+	DeletionPhaseCNI DeletionPhase = "cni"
+	// DeletionPhaseVRS removes the vrs
+	DeletionPhaseVRS DeletionPhase = "vrs"
+	// DeletionPhaseCleanup removes the nuage files from the nodes
+	DeletionPhaseCleanup DeletionPhase = "cleanup"
+	// DeletionPhaseDone is set once all components are removed
+	DeletionPhaseDone DeletionPhase = "done"
+)
+
+// DeletionStatus reports the progress of the teardown
+type DeletionStatus struct {
+	Phase          DeletionPhase `json:"phase"`
+	PhaseStartTime metav1.Time   `json:"phaseStartTime"`
+	Message        string        `json:"message,omitempty"`
 }
 
 // NuageCNIConfigStatus defines the observed state of NuageCNIConfig
 // +k8s:openapi-gen=true
 type NuageCNIConfigStatus struct {
+	Conditions      []Condition         `json:"conditions,omitempty"`
+	Deletion        *DeletionStatus     `json:"deletion,omitempty"`
+	ManagementState ManagementState     `json:"managementState,omitempty"`
+	MasterNodes     MasterNodesStatus   `json:"masterNodes,omitempty"`
+	VSDEndpoints    []VSDEndpointStatus `json:"vsdEndpoints,omitempty"`
+}
+
+// VSDEndpointStatus is the health of a VSD endpoint as last checked by the
+// operator. Reason is set when it is not healthy, message holds the outcome
+// of the last check
+type VSDEndpointStatus struct {
+	VSDEndpoint `json:",inline"`
+	Healthy     bool   `json:"healthy"`
+	Reason      string `json:"reason,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// MasterNodesStatus lists the nodes labelled to run the monitor
+type MasterNodesStatus struct {
+	Count int      `json:"count"`
+	Nodes []string `json:"nodes,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -180,6 +383,7 @@ type ClusterNetworkConfigDefinition struct {
 	ClusterNetworkCIDR         string
 	ServiceNetworkCIDR         string
 	ClusterNetworkSubnetLength uint32
+	ClusterNetworkMTU          int
 }
 
 // TLSCertificates contains certificates for CNI and Monitor
@@ -188,15 +392,29 @@ type TLSCertificates struct {
 	Certificate    *string
 	PrivateKey     *string
 	CertificateDir *string
+	Hosts          []string
+}
+
+// ClusterProxyConfig is the proxy the nuage components use. NoProxy includes
+// the cluster networks
+type ClusterProxyConfig struct {
+	HTTPProxy          string
+	HTTPSProxy         string
+	NoProxy            string
+	TrustedCAConfigMap string
 }
 
 // RenderConfig container to hold config data that is passed to rendering logic
 type RenderConfig struct {
 	NuageCNIConfigSpec
+	Orchestrator         string
 	K8SAPIServerURL      string
-	ServiceAccountToken  string
+	ClusterCA            string
+	VSDCA                string
 	Certificates         *TLSCertificates
 	ClusterNetworkConfig *ClusterNetworkConfigDefinition
+	Proxy                ClusterProxyConfig
+	HostCleanup          bool
 }
 
 // CertGenConfig certificate data for input generation
@@ -205,6 +423,7 @@ type CertGenConfig struct {
 	ValidFrom  *string
 	ValidFor   time.Duration
 	RSABits    int
+	Hosts      []string
 }
 
 func init() {
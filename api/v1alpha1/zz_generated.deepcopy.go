@@ -25,12 +25,17 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CNIConfigDefinition) DeepCopyInto(out *CNIConfigDefinition) {
 	*out = *in
+	out.MTU = in.MTU
+	in.Placement.DeepCopyInto(&out.Placement)
+	in.Resources.DeepCopyInto(&out.Resources)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CNIConfigDefinition.
@@ -56,6 +61,11 @@ func (in *CertGenConfig) DeepCopyInto(out *CertGenConfig) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertGenConfig.
@@ -83,6 +93,98 @@ func (in *ClusterNetworkConfigDefinition) DeepCopy() *ClusterNetworkConfigDefini
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterProxyConfig) DeepCopyInto(out *ClusterProxyConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterProxyConfig.
+func (in *ClusterProxyConfig) DeepCopy() *ClusterProxyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterProxyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionConfigDefinition) DeepCopyInto(out *DeletionConfigDefinition) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionConfigDefinition.
+func (in *DeletionConfigDefinition) DeepCopy() *DeletionConfigDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionConfigDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeletionStatus) DeepCopyInto(out *DeletionStatus) {
+	*out = *in
+	in.PhaseStartTime.DeepCopyInto(&out.PhaseStartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeletionStatus.
+func (in *DeletionStatus) DeepCopy() *DeletionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DeletionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdConfigDefinition) DeepCopyInto(out *EtcdConfigDefinition) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClientCertSecret != nil {
+		in, out := &in.ClientCertSecret, &out.ClientCertSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdConfigDefinition.
+func (in *EtcdConfigDefinition) DeepCopy() *EtcdConfigDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdConfigDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Flags) DeepCopyInto(out *Flags) {
 	*out = *in
@@ -101,6 +203,8 @@ func (in *Flags) DeepCopy() *Flags {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InfraPodConfigDefenition) DeepCopyInto(out *InfraPodConfigDefenition) {
 	*out = *in
+	in.Placement.DeepCopyInto(&out.Placement)
+	in.Resources.DeepCopyInto(&out.Resources)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InfraPodConfigDefenition.
@@ -113,6 +217,26 @@ func (in *InfraPodConfigDefenition) DeepCopy() *InfraPodConfigDefenition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MasterNodesStatus) DeepCopyInto(out *MasterNodesStatus) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MasterNodesStatus.
+func (in *MasterNodesStatus) DeepCopy() *MasterNodesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MasterNodesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metadata) DeepCopyInto(out *Metadata) {
 	*out = *in
@@ -131,8 +255,18 @@ func (in *Metadata) DeepCopy() *Metadata {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MonitorConfigDefinition) DeepCopyInto(out *MonitorConfigDefinition) {
 	*out = *in
+	if in.VSDEndpoints != nil {
+		in, out := &in.VSDEndpoints, &out.VSDEndpoints
+		*out = make([]VSDEndpoint, len(*in))
+		copy(*out, *in)
+	}
 	out.VSDMetadata = in.VSDMetadata
 	out.VSDFlags = in.VSDFlags
+	in.VSDCA.DeepCopyInto(&out.VSDCA)
+	in.Etcd.DeepCopyInto(&out.Etcd)
+	out.Service = in.Service
+	in.Placement.DeepCopyInto(&out.Placement)
+	in.Resources.DeepCopyInto(&out.Resources)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitorConfigDefinition.
@@ -145,13 +279,28 @@ func (in *MonitorConfigDefinition) DeepCopy() *MonitorConfigDefinition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitorServiceDefinition) DeepCopyInto(out *MonitorServiceDefinition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitorServiceDefinition.
+func (in *MonitorServiceDefinition) DeepCopy() *MonitorServiceDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitorServiceDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NuageCNIConfig) DeepCopyInto(out *NuageCNIConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NuageCNIConfig.
@@ -208,10 +357,14 @@ func (in *NuageCNIConfigList) DeepCopyObject() runtime.Object {
 func (in *NuageCNIConfigSpec) DeepCopyInto(out *NuageCNIConfigSpec) {
 	*out = *in
 	in.VRSConfig.DeepCopyInto(&out.VRSConfig)
-	out.CNIConfig = in.CNIConfig
-	out.MonitorConfig = in.MonitorConfig
+	in.CNIConfig.DeepCopyInto(&out.CNIConfig)
+	in.MonitorConfig.DeepCopyInto(&out.MonitorConfig)
 	out.ReleaseConfig = in.ReleaseConfig
 	out.PodNetworkConfig = in.PodNetworkConfig
+	in.InfraConfig.DeepCopyInto(&out.InfraConfig)
+	in.DeletionConfig.DeepCopyInto(&out.DeletionConfig)
+	out.RBAC = in.RBAC
+	in.Proxy.DeepCopyInto(&out.Proxy)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NuageCNIConfigSpec.
@@ -227,6 +380,24 @@ func (in *NuageCNIConfigSpec) DeepCopy() *NuageCNIConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NuageCNIConfigStatus) DeepCopyInto(out *NuageCNIConfigStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Deletion != nil {
+		in, out := &in.Deletion, &out.Deletion
+		*out = new(DeletionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	in.MasterNodes.DeepCopyInto(&out.MasterNodes)
+	if in.VSDEndpoints != nil {
+		in, out := &in.VSDEndpoints, &out.VSDEndpoints
+		*out = make([]VSDEndpointStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NuageCNIConfigStatus.
@@ -239,6 +410,40 @@ func (in *NuageCNIConfigStatus) DeepCopy() *NuageCNIConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementDefinition) DeepCopyInto(out *PlacementDefinition) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementDefinition.
+func (in *PlacementDefinition) DeepCopy() *PlacementDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodNetworkConfigDefinition) DeepCopyInto(out *PodNetworkConfigDefinition) {
 	*out = *in
@@ -254,6 +459,41 @@ func (in *PodNetworkConfigDefinition) DeepCopy() *PodNetworkConfigDefinition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyConfigDefinition) DeepCopyInto(out *ProxyConfigDefinition) {
+	*out = *in
+	if in.TrustedCA != nil {
+		in, out := &in.TrustedCA, &out.TrustedCA
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyConfigDefinition.
+func (in *ProxyConfigDefinition) DeepCopy() *ProxyConfigDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyConfigDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RBACConfigDefinition) DeepCopyInto(out *RBACConfigDefinition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RBACConfigDefinition.
+func (in *RBACConfigDefinition) DeepCopy() *RBACConfigDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(RBACConfigDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RegistryConfig) DeepCopyInto(out *RegistryConfig) {
 	*out = *in
@@ -299,6 +539,7 @@ func (in *RenderConfig) DeepCopyInto(out *RenderConfig) {
 		*out = new(ClusterNetworkConfigDefinition)
 		**out = **in
 	}
+	out.Proxy = in.Proxy
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RenderConfig.
@@ -334,6 +575,11 @@ func (in *TLSCertificates) DeepCopyInto(out *TLSCertificates) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSCertificates.
@@ -354,6 +600,18 @@ func (in *VRSConfigDefinition) DeepCopyInto(out *VRSConfigDefinition) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ActiveControllers != nil {
+		in, out := &in.ActiveControllers, &out.ActiveControllers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StandbyControllers != nil {
+		in, out := &in.StandbyControllers, &out.StandbyControllers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Placement.DeepCopyInto(&out.Placement)
+	in.Resources.DeepCopyInto(&out.Resources)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VRSConfigDefinition.
@@ -365,3 +623,59 @@ func (in *VRSConfigDefinition) DeepCopy() *VRSConfigDefinition {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSDCADefinition) DeepCopyInto(out *VSDCADefinition) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSDCADefinition.
+func (in *VSDCADefinition) DeepCopy() *VSDCADefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(VSDCADefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSDEndpoint) DeepCopyInto(out *VSDEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSDEndpoint.
+func (in *VSDEndpoint) DeepCopy() *VSDEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(VSDEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VSDEndpointStatus) DeepCopyInto(out *VSDEndpointStatus) {
+	*out = *in
+	out.VSDEndpoint = in.VSDEndpoint
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VSDEndpointStatus.
+func (in *VSDEndpointStatus) DeepCopy() *VSDEndpointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VSDEndpointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
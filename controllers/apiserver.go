@@ -0,0 +1,250 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	configv1 "github.com/openshift/api/config/v1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	//KubernetesService is the service fronting the api servers
+	KubernetesService = "kubernetes"
+	//KubernetesServiceNamespace is the namespace of the kubernetes service
+	KubernetesServiceNamespace = "default"
+	//InfrastructureName is the name of the openshift infrastructure config
+	InfrastructureName = "cluster"
+
+	apiServerPortName = "https"
+
+	kubeConfigConfigMap = "nuage-kubeconfig"
+	apiServerURLKey     = "api_server_url"
+)
+
+// endpointSliceListV1 is the list kind of the discovery.k8s.io/v1 endpoint
+// slices, the vendored client-go has no typed client for it
+var endpointSliceListV1 = schema.GroupVersionKind{Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSliceList"}
+
+//GetAPIServerURL returns the api server url the nodes use. The cni
+//config override takes precedence, then the internal api url of openshift,
+//the kubeadm control plane endpoint and the control plane endpoints behind
+//the kubernetes service. The url the operator connects to is used when none
+//of them is known
+func (r *NuageCNIConfigReconciler) GetAPIServerURL(config *operv1.CNIConfigDefinition) (string, error) {
+	if len(config.APIServerURL) != 0 {
+		return config.APIServerURL, nil
+	}
+
+	if r.orchestrator == OrchestratorOpenShift {
+		infra := &configv1.Infrastructure{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: InfrastructureName}, infra)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		if err == nil && len(infra.Status.APIServerInternalURL) != 0 {
+			return infra.Status.APIServerInternalURL, nil
+		}
+	}
+
+	if r.orchestrator == OrchestratorKubernetes {
+		url, err := r.discoverControlPlaneEndpoint()
+		if err != nil {
+			return "", err
+		}
+		if len(url) != 0 {
+			return url, nil
+		}
+	}
+
+	urls, err := r.discoverFromEndpointSlices()
+	if err != nil {
+		return "", err
+	}
+	if len(urls) == 0 {
+		if urls, err = r.discoverFromEndpoints(); err != nil {
+			return "", err
+		}
+	}
+	if len(urls) == 0 {
+		log.Warnf("api server endpoints not found, using %s", r.apiServerURL)
+		return r.apiServerURL, nil
+	}
+
+	applied, err := r.appliedAPIServerURL()
+	if err != nil {
+		return "", err
+	}
+	return pick(urls, applied), nil
+}
+
+// appliedAPIServerURL returns the api server url in the rendered kubeconfig,
+// empty when it was not rendered yet
+func (r *NuageCNIConfigReconciler) appliedAPIServerURL() (string, error) {
+	cm, err := r.clientset.CoreV1().ConfigMaps(names.Namespace).Get(context.TODO(), kubeConfigConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return cm.Data[apiServerURLKey], nil
+}
+
+// listEndpointSlices returns the slices of the kubernetes service. They are
+// read from discovery.k8s.io/v1 and converted to the v1beta1 types, which
+// hold the same fields used here. v1beta1 is only read on clusters that do
+// not serve v1, it was removed in kubernetes 1.25
+func (r *NuageCNIConfigReconciler) listEndpointSlices() ([]discoveryv1beta1.EndpointSlice, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(endpointSliceListV1)
+	err := r.Client.List(context.TODO(), list, client.InNamespace(KubernetesServiceNamespace),
+		client.MatchingLabels{discoveryv1beta1.LabelServiceName: KubernetesService})
+	if err == nil {
+		slices := make([]discoveryv1beta1.EndpointSlice, len(list.Items))
+		for i := range list.Items {
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, &slices[i]); err != nil {
+				return nil, err
+			}
+		}
+		return slices, nil
+	} else if !meta.IsNoMatchError(err) && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	slices, err := r.clientset.DiscoveryV1beta1().EndpointSlices(KubernetesServiceNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: discoveryv1beta1.LabelServiceName + "=" + KubernetesService,
+	})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return slices.Items, nil
+}
+
+// discoverFromEndpointSlices returns the urls of the ready endpoints of the
+// kubernetes service slices
+func (r *NuageCNIConfigReconciler) discoverFromEndpointSlices() ([]string, error) {
+	slices, err := r.listEndpointSlices()
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []string{}
+	for _, s := range slices {
+		port := int32(0)
+		for _, p := range s.Ports {
+			if p.Port != nil && (p.Name == nil || *p.Name == apiServerPortName) {
+				port = *p.Port
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, e := range s.Endpoints {
+			if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+				continue
+			}
+			for _, a := range e.Addresses {
+				addresses = append(addresses, apiServerURL(a, port))
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// discoverFromEndpoints returns the urls of the ready addresses of the
+// kubernetes service endpoints
+func (r *NuageCNIConfigReconciler) discoverFromEndpoints() ([]string, error) {
+	ep, err := r.clientset.CoreV1().Endpoints(KubernetesServiceNamespace).Get(context.TODO(), KubernetesService, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	addresses := []string{}
+	for _, s := range ep.Subsets {
+		port := int32(0)
+		for _, p := range s.Ports {
+			if len(p.Name) == 0 || p.Name == apiServerPortName {
+				port = p.Port
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, a := range s.Addresses {
+			addresses = append(addresses, apiServerURL(a.IP, port))
+		}
+	}
+	return addresses, nil
+}
+
+func apiServerURL(host string, port int32) string {
+	return "https://" + net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// pick keeps the applied url as long as it is among the urls, so that
+// control plane endpoints coming and going do not roll the nuage pods.
+// Otherwise the smallest url is taken, which does not depend on the order
+// the api server returns the endpoints in
+func pick(urls []string, applied string) string {
+	sort.Strings(urls)
+	for _, u := range urls {
+		if u == applied {
+			return u
+		}
+	}
+	return urls[0]
+}
+
+// apiServerEndpointsWatch is a source of the kubernetes service endpoints.
+// Only that object is listed and cached
+func (r *NuageCNIConfigReconciler) apiServerEndpointsWatch() cache.SharedIndexInformer {
+	lw := cache.NewListWatchFromClient(r.clientset.CoreV1().RESTClient(), "endpoints",
+		KubernetesServiceNamespace, fields.OneTermEqualSelector("metadata.name", KubernetesService))
+	return cache.NewSharedIndexInformer(lw, &corev1.Endpoints{}, 0, cache.Indexers{})
+}
+
+// enqueueAll maps an event to requests for every nuage cni config
+func (r *NuageCNIConfigReconciler) enqueueAll() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(handler.MapObject) []reconcile.Request {
+			list := &operv1.NuageCNIConfigList{}
+			if err := r.Client.List(context.TODO(), list); err != nil {
+				log.Errorf("listing nuage cni configs failed %v", err)
+				return nil
+			}
+			requests := []reconcile.Request{}
+			for _, i := range list.Items {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: i.Name, Namespace: i.Namespace},
+				})
+			}
+			return requests
+		}),
+	}
+}
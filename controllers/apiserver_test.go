@@ -0,0 +1,158 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// endpointSliceV1Client serves the discovery.k8s.io/v1 endpoint slices. The
+// vendored api has no v1 types, the v1beta1 ones are registered as v1
+func endpointSliceV1Client(slices ...*discoveryv1beta1.EndpointSlice) client.Client {
+	s := runtime.NewScheme()
+	gv := endpointSliceListV1.GroupVersion()
+	s.AddKnownTypeWithName(gv.WithKind("EndpointSlice"), &discoveryv1beta1.EndpointSlice{})
+	s.AddKnownTypeWithName(endpointSliceListV1, &discoveryv1beta1.EndpointSliceList{})
+	metav1.AddToGroupVersion(s, gv)
+	objs := []runtime.Object{}
+	for _, slice := range slices {
+		objs = append(objs, slice)
+	}
+	return fake.NewFakeClientWithScheme(s, objs...)
+}
+
+// noEndpointSliceV1Client fails like a cluster older than 1.21, which does
+// not serve the v1 endpoint slices
+type noEndpointSliceV1Client struct {
+	client.Client
+}
+
+func (c noEndpointSliceV1Client) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	return &meta.NoKindMatchError{GroupKind: endpointSliceListV1.GroupKind(), SearchedVersions: []string{"v1"}}
+}
+
+var kubernetesEndpoints = &corev1.Endpoints{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      KubernetesService,
+		Namespace: KubernetesServiceNamespace,
+	},
+	Subsets: []corev1.EndpointSubset{
+		{
+			Addresses: []corev1.EndpointAddress{{IP: "192.168.1.11"}, {IP: "192.168.1.10"}},
+			Ports:     []corev1.EndpointPort{{Name: "https", Port: 6443}},
+		},
+	},
+}
+
+func kubernetesEndpointSlice() *discoveryv1beta1.EndpointSlice {
+	name := "https"
+	port := int32(6443)
+	ready, notReady := true, false
+	return &discoveryv1beta1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kubernetes",
+			Namespace: KubernetesServiceNamespace,
+			Labels:    map[string]string{discoveryv1beta1.LabelServiceName: KubernetesService},
+		},
+		Endpoints: []discoveryv1beta1.Endpoint{
+			{Addresses: []string{"fd00::10"}, Conditions: discoveryv1beta1.EndpointConditions{Ready: &ready}},
+			{Addresses: []string{"fd00::1"}, Conditions: discoveryv1beta1.EndpointConditions{Ready: &notReady}},
+		},
+		Ports: []discoveryv1beta1.EndpointPort{{Name: &name, Port: &port}},
+	}
+}
+
+func TestGetAPIServerURL(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		Client:       noEndpointSliceV1Client{fake.NewFakeClient()},
+		clientset:    kubefake.NewSimpleClientset(),
+		orchestrator: OrchestratorKubernetes,
+		apiServerURL: "https://10.96.0.1:443",
+	}
+	c := &operv1.CNIConfigDefinition{}
+
+	url, err := r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://10.96.0.1:443"))
+
+	r.clientset = kubefake.NewSimpleClientset(kubernetesEndpoints)
+	url, err = r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://192.168.1.10:6443"))
+
+	//the applied url is kept while its endpoint is ready
+	applied := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: kubeConfigConfigMap, Namespace: names.Namespace},
+		Data:       map[string]string{apiServerURLKey: "https://192.168.1.11:6443"},
+	}
+	r.clientset = kubefake.NewSimpleClientset(kubernetesEndpoints, applied)
+	url, err = r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://192.168.1.11:6443"))
+
+	//the v1beta1 slices are read when v1 is not served
+	r.clientset = kubefake.NewSimpleClientset(kubernetesEndpoints, kubernetesEndpointSlice(), applied)
+	url, err = r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://[fd00::10]:6443"))
+
+	other := kubernetesEndpointSlice()
+	other.Name = "other"
+	other.Labels = map[string]string{discoveryv1beta1.LabelServiceName: "other"}
+	other.Endpoints[0].Addresses = []string{"fd00::20"}
+	r.Client = endpointSliceV1Client(kubernetesEndpointSlice(), other)
+	r.clientset = kubefake.NewSimpleClientset(kubernetesEndpoints, applied)
+	url, err = r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://[fd00::10]:6443"))
+
+	//a stable control plane endpoint is preferred on kubernetes
+	cm := kubeadmConfig.DeepCopy()
+	cm.Data[kubeadmClusterConfigKey] += "controlPlaneEndpoint: api.example.com:6443\n"
+	r.clientset = kubefake.NewSimpleClientset(kubernetesEndpoints, kubernetesEndpointSlice(), cm)
+	url, err = r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://api.example.com:6443"))
+
+	r.orchestrator = OrchestratorOpenShift
+	r.Client = fake.NewFakeClient(&configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: InfrastructureName},
+		Status:     configv1.InfrastructureStatus{APIServerInternalURL: "https://api-int.example.com:6443"},
+	})
+	url, err = r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://api-int.example.com:6443"))
+
+	c.APIServerURL = "https://api.example.com:6443"
+	url, err = r.GetAPIServerURL(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(url).To(Equal("https://api.example.com:6443"))
+}
+
+func TestPick(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	urls := []string{"https://192.168.1.12:6443", "https://192.168.1.10:6443", "https://192.168.1.11:6443"}
+	g.Expect(pick(urls, "")).To(Equal("https://192.168.1.10:6443"))
+	g.Expect(pick(urls, "https://192.168.1.12:6443")).To(Equal("https://192.168.1.12:6443"))
+	//the applied endpoint is gone
+	g.Expect(pick(urls, "https://192.168.1.13:6443")).To(Equal("https://192.168.1.10:6443"))
+}
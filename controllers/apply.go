@@ -8,6 +8,8 @@ import (
 	"context"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -28,9 +30,26 @@ func (r *NuageCNIConfigReconciler) ApplyObject(nsn types.NamespacedName, obj run
 		return err
 	}
 
+	//the token controller fills in the data of a service account token
+	//secret, an update would drop the token
+	if isServiceAccountToken(obj) {
+		return nil
+	}
+
 	err = r.Client.Update(context.TODO(), obj)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// isServiceAccountToken tells if obj is a service account token secret
+func isServiceAccountToken(obj runtime.Object) bool {
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		return o.Type == corev1.SecretTypeServiceAccountToken
+	case *unstructured.Unstructured:
+		return o.GetKind() == "Secret" && o.Object["type"] == string(corev1.SecretTypeServiceAccountToken)
+	}
+	return false
+}
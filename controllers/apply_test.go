@@ -0,0 +1,47 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestApplyObjectServiceAccountToken(t *testing.T) {
+	g := NewGomegaWithT(t)
+	nsn := types.NamespacedName{Name: "nuage-cni", Namespace: names.Namespace}
+
+	//the token controller filled in the token
+	r := &NuageCNIConfigReconciler{Client: fake.NewFakeClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: nsn.Name, Namespace: nsn.Namespace},
+		Type:       corev1.SecretTypeServiceAccountToken,
+		Data:       map[string][]byte{"token": []byte("token")},
+	})}
+
+	rendered := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": nsn.Name, "namespace": nsn.Namespace},
+		"type":       string(corev1.SecretTypeServiceAccountToken),
+	}}
+	g.Expect(r.ApplyObject(nsn, rendered)).To(Succeed())
+
+	s := &corev1.Secret{}
+	g.Expect(r.Client.Get(context.TODO(), nsn, s)).To(Succeed())
+	g.Expect(s.Data).To(HaveKeyWithValue("token", []byte("token")))
+
+	//other secrets are updated
+	g.Expect(isServiceAccountToken(&corev1.Secret{Type: corev1.SecretTypeOpaque})).To(BeFalse())
+	g.Expect(isServiceAccountToken(&corev1.Secret{Type: corev1.SecretTypeServiceAccountToken})).To(BeTrue())
+	g.Expect(isServiceAccountToken(&corev1.ConfigMap{})).To(BeFalse())
+}
@@ -14,6 +14,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net"
 	"time"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
@@ -71,6 +72,7 @@ func GenerateCertificates(config *operv1.CertGenConfig) (*operv1.TLSCertificates
 		CA:          &certificate,
 		Certificate: &certificate,
 		PrivateKey:  &privatekey,
+		Hosts:       config.Hosts,
 	}, nil
 
 }
@@ -123,6 +125,14 @@ func GenerateCertificateTemplate(config *operv1.CertGenConfig) (*x509.Certificat
 		IsCA:                  true,
 	}
 
+	for _, h := range config.Hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
 	return template, nil
 }
 
@@ -201,3 +211,17 @@ func fillDefaults(config *operv1.CertGenConfig) {
 		config.ValidFrom = &validFrom
 	}
 }
+
+//CoversHosts returns true if the certificates were generated for all the hosts
+func CoversHosts(c *operv1.TLSCertificates, hosts []string) bool {
+	generated := map[string]bool{}
+	for _, h := range c.Hosts {
+		generated[h] = true
+	}
+	for _, h := range hosts {
+		if !generated[h] {
+			return false
+		}
+	}
+	return true
+}
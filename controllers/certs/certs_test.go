@@ -53,6 +53,14 @@ func TestGenerateCertificateTemplate(t *testing.T) {
 	g.Expect(cert.NotBefore).Should(BeTemporally("~", time.Now()))
 	g.Expect(cert.NotAfter).Should(BeTemporally("~", time.Now().Add(time.Hour)))
 	g.Expect(cert.IsCA).Should(BeTrue())
+	g.Expect(cert.DNSNames).To(BeEmpty())
+
+	config.Hosts = []string{"10.96.0.20", "nuage-monitor.nuage-network-operator.svc"}
+	cert, err = GenerateCertificateTemplate(config)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cert.IPAddresses).To(HaveLen(1))
+	g.Expect(cert.IPAddresses[0].String()).To(Equal("10.96.0.20"))
+	g.Expect(cert.DNSNames).To(Equal([]string{"nuage-monitor.nuage-network-operator.svc"}))
 }
 
 func TestGenerateCertificates(t *testing.T) {
@@ -80,3 +88,15 @@ func TestGenerateCertificates(t *testing.T) {
 	g.Expect(len(*c.Certificate)).ShouldNot(BeZero())
 	g.Expect(len(*c.PrivateKey)).ShouldNot(BeZero())
 }
+
+func TestCoversHosts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := &operv1.TLSCertificates{}
+	g.Expect(CoversHosts(c, nil)).To(BeTrue())
+	g.Expect(CoversHosts(c, []string{"10.96.0.20"})).To(BeFalse())
+
+	c.Hosts = []string{"10.96.0.20", "nuage-monitor"}
+	g.Expect(CoversHosts(c, []string{"nuage-monitor"})).To(BeTrue())
+	g.Expect(CoversHosts(c, []string{"nuage-monitor", "10.96.0.21"})).To(BeFalse())
+}
@@ -6,12 +6,13 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"strings"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
 	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
-	"github.com/nuagenetworks/nuage-network-operator/controllers/network/cni"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/vrs"
 	iputil "github.com/nuagenetworks/nuage-network-operator/controllers/util/ip"
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/pkg/errors"
@@ -22,10 +23,40 @@ import (
 )
 
 const (
-	//DefaultServiceNetworkCIDR is the default service cidr used by kubernetes as of v1.15
-	DefaultServiceNetworkCIDR string = "10.0.0.0/24"
+	//DefaultServiceNetworkCIDR is the default service cidr used by kubeadm
+	DefaultServiceNetworkCIDR string = "10.96.0.0/12"
+	//DefaultClusterNetworkSubnetLength is the default node cidr mask size used by kube-controller-manager
+	DefaultClusterNetworkSubnetLength uint32 = 24
+	//ReasonClusterNetworkMismatch is reported when the cr does not match the cluster network
+	ReasonClusterNetworkMismatch = "ClusterNetworkMismatch"
+	//ReasonClusterNetworkUnknown is reported when the cluster network could not be determined
+	ReasonClusterNetworkUnknown = "ClusterNetworkUnknown"
+	//ReasonClusterNetworkInvalid is reported when the cluster network fails validation
+	ReasonClusterNetworkInvalid = "ClusterNetworkInvalid"
+	//ReasonPodNetworkChangeRejected is reported when the pod network is changed after install
+	ReasonPodNetworkChangeRejected = "PodNetworkChangeRejected"
+	//ClusterNetworkConfigName is the name of the openshift cluster network config
+	ClusterNetworkConfigName = "cluster"
 )
 
+//HostAddress is the address of a host that must not be part of the
+//pod or service network
+type HostAddress struct {
+	Name    string
+	Address string
+}
+
+//ClusterNetworkError is returned when the cluster network config cannot be
+//used. It is reported through the status instead of being retried
+type ClusterNetworkError struct {
+	Reason  string
+	Message string
+}
+
+func (e *ClusterNetworkError) Error() string {
+	return e.Message
+}
+
 // GetClusterNetworkInfo fetches the cluster network configuration from API server
 func (r *NuageCNIConfigReconciler) GetClusterNetworkInfo() (*operv1.ClusterNetworkConfigDefinition, error) {
 	if r.orchestrator == OrchestratorKubernetes {
@@ -35,7 +66,24 @@ func (r *NuageCNIConfigReconciler) GetClusterNetworkInfo() (*operv1.ClusterNetwo
 	return r.GetOSEClusterNetworkInfo()
 }
 
-//GetK8SClusterNetworkInfo fetches service cidr from k8s api server
+// removableClusterNetwork drops a ClusterNetworkError while removing. The
+// components are torn down whatever the cluster network is, with the config
+// discovered so far or an empty one
+func removableClusterNetwork(c *operv1.ClusterNetworkConfigDefinition, err error, removing bool) (*operv1.ClusterNetworkConfigDefinition, error) {
+	cerr, ok := err.(*ClusterNetworkError)
+	if !ok || !removing {
+		return c, err
+	}
+
+	log.Warnf("ignoring the cluster network config while removing %v", cerr)
+	if c == nil {
+		c = &operv1.ClusterNetworkConfigDefinition{}
+	}
+	return c, nil
+}
+
+//GetK8SClusterNetworkInfo merges the pod network config from crd with the
+//one discovered from the cluster
 func (r *NuageCNIConfigReconciler) GetK8SClusterNetworkInfo() (*operv1.ClusterNetworkConfigDefinition, error) {
 
 	//if k8s, cluster network and cluster network subnet length
@@ -46,28 +94,114 @@ func (r *NuageCNIConfigReconciler) GetK8SClusterNetworkInfo() (*operv1.ClusterNe
 		ServiceNetworkCIDR:         r.ClusterServiceNetworkCIDR,
 	}
 
-	if len(r.ClusterServiceNetworkCIDR) == 0 {
+	d, err := r.DiscoverK8SClusterNetwork()
+	if err != nil {
+		log.Errorf("discovering cluster network failed %v", err)
+		return nil, err
+	}
+
+	if err := mergeClusterNetwork(c, d); err != nil {
+		return nil, err
+	}
+
+	if len(c.ClusterNetworkCIDR) == 0 {
+		return nil, &ClusterNetworkError{
+			Reason:  ReasonClusterNetworkUnknown,
+			Message: "pod network is neither set in podNetworkConfig nor discoverable from the cluster",
+		}
+	}
+
+	if len(c.ServiceNetworkCIDR) == 0 {
+		log.Warnf("service network not found, using default %s", DefaultServiceNetworkCIDR)
 		c.ServiceNetworkCIDR = DefaultServiceNetworkCIDR
 	}
 
+	if c.ClusterNetworkSubnetLength == 0 {
+		c.ClusterNetworkSubnetLength = DefaultClusterNetworkSubnetLength
+	}
+
 	return c, nil
 }
 
-// GetOSEClusterNetworkInfo fetches network config from api server
+// mergeClusterNetwork fills the empty fields of c from the discovered config d
+// and fails if a field set in both does not match
+func mergeClusterNetwork(c, d *operv1.ClusterNetworkConfigDefinition) error {
+	mismatch := func(field, configured, discovered string) error {
+		return &ClusterNetworkError{
+			Reason: ReasonClusterNetworkMismatch,
+			Message: fmt.Sprintf("podNetworkConfig.%s %s does not match %s configured in the cluster",
+				field, configured, discovered),
+		}
+	}
+
+	if len(c.ClusterNetworkCIDR) == 0 {
+		c.ClusterNetworkCIDR = d.ClusterNetworkCIDR
+	} else if len(d.ClusterNetworkCIDR) != 0 && !sameCIDR(c.ClusterNetworkCIDR, d.ClusterNetworkCIDR) {
+		return mismatch("podNetwork", c.ClusterNetworkCIDR, d.ClusterNetworkCIDR)
+	}
+
+	if len(c.ServiceNetworkCIDR) == 0 {
+		c.ServiceNetworkCIDR = d.ServiceNetworkCIDR
+	} else if len(d.ServiceNetworkCIDR) != 0 && !sameCIDR(c.ServiceNetworkCIDR, d.ServiceNetworkCIDR) {
+		return mismatch("ClusterServiceNetworkCIDR", c.ServiceNetworkCIDR, d.ServiceNetworkCIDR)
+	}
+
+	if c.ClusterNetworkSubnetLength == 0 {
+		c.ClusterNetworkSubnetLength = d.ClusterNetworkSubnetLength
+	} else if d.ClusterNetworkSubnetLength != 0 && c.ClusterNetworkSubnetLength != d.ClusterNetworkSubnetLength {
+		return mismatch("subnetLength", fmt.Sprint(c.ClusterNetworkSubnetLength), fmt.Sprint(d.ClusterNetworkSubnetLength))
+	}
+
+	return nil
+}
+
+// sameCIDR compares two cidrs ignoring host bits and formatting
+func sameCIDR(a, b string) bool {
+	_, an, aerr := net.ParseCIDR(a)
+	_, bn, berr := net.ParseCIDR(b)
+	if aerr != nil || berr != nil {
+		return a == b
+	}
+	return an.String() == bn.String()
+}
+
+// GetOSEClusterNetworkInfo fetches network config from api server. The pod
+// network of an installed cluster, the one recorded in the status, cannot be
+// changed
 func (r *NuageCNIConfigReconciler) GetOSEClusterNetworkInfo() (*operv1.ClusterNetworkConfigDefinition, error) {
 	clusterConfig := &configv1.Network{}
-	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: "network"}, clusterConfig)
-	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			return nil, err
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterNetworkConfigName}, clusterConfig)
+	if k8serrors.IsNotFound(err) {
+		return nil, &ClusterNetworkError{
+			Reason:  ReasonClusterNetworkUnknown,
+			Message: fmt.Sprintf("network config %q of the cluster not found", ClusterNetworkConfigName),
 		}
+	} else if err != nil {
 		return nil, err
 	}
 
 	// Validate the cluster config
 	if err = ValidateOSEClusterConfig(clusterConfig.Spec); err != nil {
 		log.Errorf("Failed to validate Network.Spec %v", err)
-		return nil, nil
+		return nil, &ClusterNetworkError{
+			Reason:  ReasonClusterNetworkInvalid,
+			Message: fmt.Sprintf("network config %q of the cluster is not valid: %v", ClusterNetworkConfigName, err),
+		}
+	}
+
+	//A rejected change still returns the applied network, the components
+	//are removed with the config they were installed with
+	if err := validatePodNetworkChange(clusterConfig); err != nil {
+		applied := clusterConfig.Status.ClusterNetwork[0]
+		serviceNetwork := clusterConfig.Spec.ServiceNetwork[0]
+		if len(clusterConfig.Status.ServiceNetwork) != 0 {
+			serviceNetwork = clusterConfig.Status.ServiceNetwork[0]
+		}
+		return &operv1.ClusterNetworkConfigDefinition{
+			ClusterNetworkCIDR:         applied.CIDR,
+			ServiceNetworkCIDR:         serviceNetwork,
+			ClusterNetworkSubnetLength: applied.HostPrefix,
+		}, err
 	}
 
 	networkInfo := &operv1.ClusterNetworkConfigDefinition{
@@ -78,6 +212,26 @@ func (r *NuageCNIConfigReconciler) GetOSEClusterNetworkInfo() (*operv1.ClusterNe
 	return networkInfo, nil
 }
 
+// validatePodNetworkChange rejects a pod network in the spec that differs from
+// the one applied at install. The nodes keep the subnets allocated from the
+// applied one, so the change cannot be rolled out
+func validatePodNetworkChange(c *configv1.Network) error {
+	if len(c.Status.ClusterNetwork) == 0 {
+		return nil
+	}
+
+	applied, requested := c.Status.ClusterNetwork[0], c.Spec.ClusterNetwork[0]
+	if sameCIDR(applied.CIDR, requested.CIDR) && applied.HostPrefix == requested.HostPrefix {
+		return nil
+	}
+	return &ClusterNetworkError{
+		Reason: ReasonPodNetworkChangeRejected,
+		Message: fmt.Sprintf("spec.clusterNetwork of network config %q changed from %s with host prefix %d to %s with host prefix %d, "+
+			"the pod network cannot be changed after install, revert the change to resume reconciling",
+			ClusterNetworkConfigName, applied.CIDR, applied.HostPrefix, requested.CIDR, requested.HostPrefix),
+	}
+}
+
 // ValidateOSEClusterConfig ensures the cluster config is valid.
 func ValidateOSEClusterConfig(clusterConfig configv1.NetworkSpec) error {
 	// Check all networks for overlaps
@@ -128,8 +282,32 @@ func ValidateOSEClusterConfig(clusterConfig configv1.NetworkSpec) error {
 	return nil
 }
 
+//ValidateK8SClusterNetwork validates the cluster network against the node
+//addresses and the vsc controllers
+func (r *NuageCNIConfigReconciler) ValidateK8SClusterNetwork(c *operv1.ClusterNetworkConfigDefinition, vrsConfig *operv1.VRSConfigDefinition) error {
+	hosts, err := r.ListNodeAddresses()
+	if err != nil {
+		log.Errorf("listing node addresses failed %v", err)
+		return err
+	}
+
+	active, standby := vrs.Controllers(vrsConfig)
+	controllers := append(append([]string{}, active...), standby...)
+	for _, controller := range controllers {
+		hosts = append(hosts, HostAddress{Name: "vsc controller", Address: controller})
+	}
+
+	if err := ValidateK8SClusterConfig(c, hosts); err != nil {
+		return &ClusterNetworkError{
+			Reason:  ReasonClusterNetworkInvalid,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
 // ValidateK8SClusterConfig validates the cluster config for k8s
-func ValidateK8SClusterConfig(c *operv1.ClusterNetworkConfigDefinition) error {
+func ValidateK8SClusterConfig(c *operv1.ClusterNetworkConfigDefinition, hosts []HostAddress) error {
 	// Check all networks for overlaps
 	pool := iputil.IPPool{}
 
@@ -161,6 +339,34 @@ func ValidateK8SClusterConfig(c *operv1.ClusterNetworkConfigDefinition) error {
 		return errors.Errorf("subnet length %d is too small, must be a /30 or larger",
 			c.ClusterNetworkSubnetLength)
 	}
+
+	return validateHostAddresses(c, hosts)
+}
+
+// validateHostAddresses ensures none of the host addresses fall in the
+// pod or service network. Addresses that are not ips are skipped
+func validateHostAddresses(c *operv1.ClusterNetworkConfigDefinition, hosts []HostAddress) error {
+	networks := map[string]string{
+		"pod network":     c.ClusterNetworkCIDR,
+		"service network": c.ServiceNetworkCIDR,
+	}
+
+	for _, h := range hosts {
+		ip := net.ParseIP(h.Address)
+		if ip == nil {
+			continue
+		}
+		for _, name := range []string{"pod network", "service network"} {
+			_, cidr, err := net.ParseCIDR(networks[name])
+			if err != nil {
+				return errors.Errorf("invalid %s cidr found %v", name, networks[name])
+			}
+			if cidr.Contains(ip) {
+				return errors.Errorf("address %s of %s overlaps with %s %s",
+					h.Address, h.Name, name, networks[name])
+			}
+		}
+	}
 	return nil
 }
 
@@ -172,7 +378,7 @@ func (r *NuageCNIConfigReconciler) UpdateClusterNetworkStatus(c *operv1.ClusterN
 
 	clusterConfig := &configv1.Network{
 		TypeMeta:   metav1.TypeMeta{APIVersion: configv1.GroupVersion.String(), Kind: "Network"},
-		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterNetworkConfigName},
 		Status: configv1.NetworkStatus{
 			ClusterNetwork: []configv1.ClusterNetworkEntry{
 				{
@@ -182,7 +388,7 @@ func (r *NuageCNIConfigReconciler) UpdateClusterNetworkStatus(c *operv1.ClusterN
 			},
 			ServiceNetwork:    []string{c.ServiceNetworkCIDR},
 			NetworkType:       names.NuageSDN,
-			ClusterNetworkMTU: cni.MTU,
+			ClusterNetworkMTU: c.ClusterNetworkMTU,
 		},
 	}
 
@@ -203,7 +409,8 @@ func (r *NuageCNIConfigReconciler) UpdateClusterNetworkStatus(c *operv1.ClusterN
 
 	tmp.Status = clusterConfig.Status
 
-	err = r.Client.Update(context.TODO(), tmp)
+	//status is a subresource of the network config
+	err = r.Client.Status().Update(context.TODO(), tmp)
 	if err != nil {
 		return err
 	}
@@ -14,7 +14,10 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	osv1 "github.com/openshift/api/route/v1"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -28,7 +31,7 @@ func init() {
 	if err != nil {
 		testlog.Error(err, "Failed to install openshift")
 	}
-	s.AddKnownTypes(configv1.SchemeGroupVersion, &configv1.Network{})
+	s.AddKnownTypes(configv1.SchemeGroupVersion, &configv1.Network{}, &configv1.Infrastructure{}, &configv1.ClusterOperator{}, &configv1.Proxy{})
 }
 
 func TestClusterConfigUpdateStatus(t *testing.T) {
@@ -77,7 +80,7 @@ func TestClusterConfigGet(t *testing.T) {
 
 	c := &configv1.Network{
 		TypeMeta:   metav1.TypeMeta{APIVersion: configv1.GroupVersion.String(), Kind: "Network"},
-		ObjectMeta: metav1.ObjectMeta{Name: "network"},
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterNetworkConfigName},
 		Spec: configv1.NetworkSpec{
 			ClusterNetwork: []configv1.ClusterNetworkEntry{
 				{CIDR: "70.70.0.0/16", HostPrefix: 24},
@@ -99,6 +102,93 @@ func TestClusterConfigGet(t *testing.T) {
 
 }
 
+func TestClusterConfigGetInvalid(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		Client:       fake.NewFakeClient(),
+		orchestrator: OrchestratorOpenShift,
+	}
+
+	_, err := r.GetOSEClusterNetworkInfo()
+	cerr, ok := err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonClusterNetworkUnknown))
+
+	c := &configv1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterNetworkConfigName},
+		Spec: configv1.NetworkSpec{
+			ClusterNetwork: []configv1.ClusterNetworkEntry{
+				{CIDR: "70.70.0.0/16", HostPrefix: 24},
+			},
+			ServiceNetwork: []string{"192.168.0.0/16"},
+			NetworkType:    "OpenShiftSDN",
+		},
+	}
+	g.Expect(r.Client.Create(context.TODO(), c)).To(Succeed())
+
+	cnf, err := r.GetOSEClusterNetworkInfo()
+	g.Expect(cnf).To(BeNil())
+	cerr, ok = err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonClusterNetworkInvalid))
+	g.Expect(cerr.Message).To(ContainSubstring("is not supported"))
+
+	//an invalid or missing network config does not block removing
+	cnf, err = removableClusterNetwork(cnf, err, true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cnf).To(Equal(&operv1.ClusterNetworkConfigDefinition{}))
+}
+
+func TestClusterConfigPodNetworkChange(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		Client:       fake.NewFakeClient(),
+		orchestrator: OrchestratorOpenShift,
+	}
+
+	c := &configv1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: ClusterNetworkConfigName},
+		Spec: configv1.NetworkSpec{
+			ClusterNetwork: []configv1.ClusterNetworkEntry{
+				{CIDR: "70.70.0.0/16", HostPrefix: 24},
+			},
+			ServiceNetwork: []string{"192.168.0.0/16"},
+			NetworkType:    names.NuageSDN,
+		},
+	}
+	g.Expect(r.Client.Create(context.TODO(), c)).To(Succeed())
+
+	//the status records the pod network applied at install
+	cnf, err := r.GetOSEClusterNetworkInfo()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(r.UpdateClusterNetworkStatus(cnf)).To(Succeed())
+
+	g.Expect(r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterNetworkConfigName}, c)).To(Succeed())
+	g.Expect(c.Status.ClusterNetwork).To(Equal(c.Spec.ClusterNetwork))
+	c.Spec.ClusterNetwork[0].HostPrefix = 23
+	g.Expect(r.Client.Update(context.TODO(), c)).To(Succeed())
+
+	cnf, err = r.GetOSEClusterNetworkInfo()
+	cerr, ok := err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonPodNetworkChangeRejected))
+	g.Expect(cerr.Message).To(ContainSubstring("changed from 70.70.0.0/16 with host prefix 24 to 70.70.0.0/16 with host prefix 23"))
+
+	//removing tears the components down with the applied pod network
+	cnf, err = removableClusterNetwork(cnf, err, true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cnf.ClusterNetworkCIDR).To(Equal("70.70.0.0/16"))
+	g.Expect(cnf.ClusterNetworkSubnetLength).To(Equal(uint32(24)))
+	g.Expect(cnf.ServiceNetworkCIDR).To(Equal("192.168.0.0/16"))
+
+	c.Spec.ClusterNetwork[0] = configv1.ClusterNetworkEntry{CIDR: "70.70.0.0/16", HostPrefix: 24}
+	g.Expect(r.Client.Update(context.TODO(), c)).To(Succeed())
+	_, err = r.GetOSEClusterNetworkInfo()
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
 func TestClusterConfigValidateOSE(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -250,7 +340,7 @@ func TestClusterConfigValidateK8S(t *testing.T) {
 	}
 
 	for _, tt := range vec {
-		err := ValidateK8SClusterConfig(tt.in)
+		err := ValidateK8SClusterConfig(tt.in, nil)
 		if tt.out == nil {
 			g.Expect(err).To(BeNil())
 		} else {
@@ -259,3 +349,125 @@ func TestClusterConfigValidateK8S(t *testing.T) {
 	}
 
 }
+
+func TestClusterConfigGetK8S(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		clientset:    k8sfake.NewSimpleClientset(kubeadmConfig),
+		orchestrator: OrchestratorKubernetes,
+	}
+
+	cnf, err := r.GetClusterNetworkInfo()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cnf.ClusterNetworkCIDR).To(Equal("70.70.0.0/16"))
+	g.Expect(cnf.ServiceNetworkCIDR).To(Equal("10.96.0.0/12"))
+	g.Expect(cnf.ClusterNetworkSubnetLength).To(Equal(uint32(26)))
+
+	r.setPodNetworkConfig(&operv1.PodNetworkConfigDefinition{
+		ClusterNetworkCIDR: "70.70.0.0/16",
+		SubnetLength:       26,
+	})
+	_, err = r.GetClusterNetworkInfo()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	r.setPodNetworkConfig(&operv1.PodNetworkConfigDefinition{
+		ClusterNetworkCIDR:        "70.70.0.0/16",
+		ClusterServiceNetworkCIDR: "192.168.0.0/16",
+	})
+	_, err = r.GetClusterNetworkInfo()
+	g.Expect(err).To(HaveOccurred())
+	cerr, ok := err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonClusterNetworkMismatch))
+	g.Expect(cerr.Message).To(ContainSubstring("192.168.0.0/16 does not match 10.96.0.0/12"))
+
+	r = &NuageCNIConfigReconciler{
+		clientset:    k8sfake.NewSimpleClientset(),
+		orchestrator: OrchestratorKubernetes,
+	}
+
+	_, err = r.GetClusterNetworkInfo()
+	cerr, ok = err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonClusterNetworkUnknown))
+
+	r.setPodNetworkConfig(&operv1.PodNetworkConfigDefinition{
+		ClusterNetworkCIDR: "70.70.0.0/16",
+	})
+	cnf, err = r.GetClusterNetworkInfo()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cnf.ServiceNetworkCIDR).To(Equal(DefaultServiceNetworkCIDR))
+	g.Expect(cnf.ClusterNetworkSubnetLength).To(Equal(DefaultClusterNetworkSubnetLength))
+}
+
+func TestClusterConfigValidateK8SHosts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := &operv1.ClusterNetworkConfigDefinition{
+		ServiceNetworkCIDR:         "192.168.0.0/16",
+		ClusterNetworkCIDR:         "70.70.0.0/16",
+		ClusterNetworkSubnetLength: 24,
+	}
+
+	err := ValidateK8SClusterConfig(c, []HostAddress{
+		{Name: "node node1", Address: "10.0.0.1"},
+		{Name: "vsc controller", Address: "vsc.example.com"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	err = ValidateK8SClusterConfig(c, []HostAddress{
+		{Name: "node node1", Address: "70.70.1.1"},
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("address 70.70.1.1 of node node1 overlaps with pod network 70.70.0.0/16"))
+
+	err = ValidateK8SClusterConfig(c, []HostAddress{
+		{Name: "vsc controller", Address: "192.168.10.1"},
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("address 192.168.10.1 of vsc controller overlaps with service network 192.168.0.0/16"))
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node1"},
+				{Type: corev1.NodeInternalIP, Address: "70.70.1.1"},
+			},
+		},
+	}
+	r := &NuageCNIConfigReconciler{
+		clientset: k8sfake.NewSimpleClientset(node),
+	}
+
+	err = r.ValidateK8SClusterNetwork(c, &operv1.VRSConfigDefinition{ActiveControllers: []string{"10.0.0.2"}})
+	g.Expect(err).To(HaveOccurred())
+	cerr, ok := err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonClusterNetworkInvalid))
+}
+
+func TestRemovableClusterNetwork(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mismatch := &ClusterNetworkError{Reason: ReasonClusterNetworkMismatch, Message: "mismatch"}
+	discovered := &operv1.ClusterNetworkConfigDefinition{ClusterNetworkCIDR: "70.70.0.0/16"}
+
+	c, err := removableClusterNetwork(discovered, mismatch, false)
+	g.Expect(err).To(Equal(mismatch))
+	g.Expect(c).To(Equal(discovered))
+
+	//the components are torn down with what was discovered
+	c, err = removableClusterNetwork(discovered, mismatch, true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c).To(Equal(discovered))
+
+	c, err = removableClusterNetwork(nil, mismatch, true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c).To(Equal(&operv1.ClusterNetworkConfigDefinition{}))
+
+	//other errors are still retried
+	_, err = removableClusterNetwork(nil, errors.New("api server down"), true)
+	g.Expect(err).To(HaveOccurred())
+}
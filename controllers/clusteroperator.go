@@ -0,0 +1,253 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	"github.com/nuagenetworks/nuage-network-operator/version"
+	configv1 "github.com/openshift/api/config/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	//ClusterOperatorName is the name of the cluster operator reporting the nuage networking status
+	ClusterOperatorName = "network-nuage"
+	//ReasonRollingOut is reported while daemonset pods are being updated
+	ReasonRollingOut = "RollingOut"
+	//ReasonUnavailable is reported when a component has no available pods
+	ReasonUnavailable = "Unavailable"
+	//ReasonRemoved is reported when the nuage components are removed
+	ReasonRemoved = "Removed"
+	//ReasonUnmanaged is reported when the operator does not manage the components
+	ReasonUnmanaged = "Unmanaged"
+)
+
+// component is a daemonset managed by the operator and the image it runs
+type component struct {
+	name  string
+	image func(*operv1.ReleaseConfigDefinition) string
+}
+
+var components = []component{
+	{"nuage-vrs", func(c *operv1.ReleaseConfigDefinition) string { return c.VRSTag }},
+	{"nuage-cni", func(c *operv1.ReleaseConfigDefinition) string { return c.CNITag }},
+	{names.NuageMonitor, func(c *operv1.ReleaseConfigDefinition) string { return c.MonitorTag }},
+	{"nuage-infra", func(c *operv1.ReleaseConfigDefinition) string { return c.InfraTag }},
+}
+
+//UpdateClusterOperator publishes the status of the nuage components in the
+//network-nuage cluster operator. The cluster operator is deleted once a
+//deleted instance is torn down. It is a no-op outside of openshift
+func (r *NuageCNIConfigReconciler) UpdateClusterOperator(instance *operv1.NuageCNIConfig, state operv1.ManagementState) error {
+	if r.orchestrator != OrchestratorOpenShift {
+		return nil
+	}
+
+	if d := instance.Status.Deletion; instance.GetDeletionTimestamp() != nil && d != nil && d.Phase == operv1.DeletionPhaseDone {
+		co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: ClusterOperatorName}}
+		if err := r.Client.Delete(context.TODO(), co); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	daemonSets := map[string]*appsv1.DaemonSet{}
+	for _, c := range components {
+		ds, err := r.clientset.AppsV1().DaemonSets(names.Namespace).Get(context.TODO(), c.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		daemonSets[c.name] = ds
+	}
+
+	co := &configv1.ClusterOperator{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterOperatorName}, co)
+	if apierrors.IsNotFound(err) {
+		co = &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: ClusterOperatorName}}
+		if err := r.Client.Create(context.TODO(), co); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	status := co.Status.DeepCopy()
+	for _, c := range clusterOperatorConditions(instance, state, daemonSets) {
+		setClusterOperatorCondition(status, c)
+	}
+	status.Versions = clusterOperatorVersions(&instance.Spec.ReleaseConfig)
+	status.RelatedObjects = clusterOperatorRelatedObjects(instance)
+
+	if reflect.DeepEqual(&co.Status, status) {
+		return nil
+	}
+	co.Status = *status
+	return r.Client.Status().Update(context.TODO(), co)
+}
+
+// clusterOperatorConditions derives the cluster operator conditions from the
+// instance status and the rollout of the daemonsets
+func clusterOperatorConditions(instance *operv1.NuageCNIConfig, state operv1.ManagementState, daemonSets map[string]*appsv1.DaemonSet) []configv1.ClusterOperatorStatusCondition {
+	degraded := configv1.ClusterOperatorStatusCondition{
+		Type:   configv1.OperatorDegraded,
+		Status: configv1.ConditionFalse,
+		Reason: ReasonAsExpected,
+	}
+	if c := GetCondition(&instance.Status, operv1.ConditionDegraded); c != nil && c.Status == corev1.ConditionTrue {
+		degraded.Status = configv1.ConditionTrue
+		degraded.Reason = c.Reason
+		degraded.Message = c.Message
+	}
+
+	upgradeable := configv1.ClusterOperatorStatusCondition{
+		Type:   configv1.OperatorUpgradeable,
+		Status: configv1.ConditionTrue,
+		Reason: ReasonAsExpected,
+	}
+	//an unmanaged instance blocks upgrades, the operator does not reconcile it
+	if state == operv1.ManagementStateUnmanaged {
+		upgradeable.Status = configv1.ConditionFalse
+		upgradeable.Reason = ReasonUnmanaged
+		upgradeable.Message = fmt.Sprintf("management state is %s", state)
+	}
+
+	available := configv1.ClusterOperatorStatusCondition{
+		Type:   configv1.OperatorAvailable,
+		Status: configv1.ConditionTrue,
+		Reason: ReasonAsExpected,
+	}
+	progressing := configv1.ClusterOperatorStatusCondition{
+		Type:   configv1.OperatorProgressing,
+		Status: configv1.ConditionFalse,
+		Reason: ReasonAsExpected,
+	}
+
+	if instance.GetDeletionTimestamp() != nil || state == operv1.ManagementStateRemoved {
+		available.Status = configv1.ConditionFalse
+		available.Reason = ReasonRemoved
+		available.Message = "nuage networking is removed"
+		if d := instance.Status.Deletion; d == nil || d.Phase != operv1.DeletionPhaseDone {
+			progressing.Status = configv1.ConditionTrue
+			progressing.Reason = ReasonRemoved
+			progressing.Message = "removing the nuage components"
+		}
+		return []configv1.ClusterOperatorStatusCondition{available, progressing, degraded, upgradeable}
+	}
+
+	unavailable, rolling := []string{}, []string{}
+	for _, c := range components {
+		ds, ok := daemonSets[c.name]
+		if !ok {
+			unavailable = append(unavailable, fmt.Sprintf("daemonset %s is not created", c.name))
+			rolling = append(rolling, fmt.Sprintf("daemonset %s is not created", c.name))
+			continue
+		}
+		s := ds.Status
+		if s.DesiredNumberScheduled > 0 && s.NumberAvailable == 0 {
+			unavailable = append(unavailable, fmt.Sprintf("daemonset %s has no available pods", c.name))
+		}
+		if s.ObservedGeneration < ds.Generation || s.UpdatedNumberScheduled < s.DesiredNumberScheduled || s.NumberUnavailable > 0 {
+			rolling = append(rolling, fmt.Sprintf("daemonset %s is rolling out, %d of %d pods updated, %d unavailable",
+				c.name, s.UpdatedNumberScheduled, s.DesiredNumberScheduled, s.NumberUnavailable))
+		}
+	}
+	if len(unavailable) != 0 {
+		available.Status = configv1.ConditionFalse
+		available.Reason = ReasonUnavailable
+		available.Message = strings.Join(unavailable, "\n")
+	}
+	if len(rolling) != 0 {
+		progressing.Status = configv1.ConditionTrue
+		progressing.Reason = ReasonRollingOut
+		progressing.Message = strings.Join(rolling, "\n")
+	}
+	return []configv1.ClusterOperatorStatusCondition{available, progressing, degraded, upgradeable}
+}
+
+// setClusterOperatorCondition adds or updates the condition, the transition
+// time only changes with the status
+func setClusterOperatorCondition(s *configv1.ClusterOperatorStatus, c configv1.ClusterOperatorStatusCondition) {
+	for i := range s.Conditions {
+		existing := &s.Conditions[i]
+		if existing.Type != c.Type {
+			continue
+		}
+		if existing.Status != c.Status {
+			existing.LastTransitionTime = metav1.Now()
+		}
+		existing.Status = c.Status
+		existing.Reason = c.Reason
+		existing.Message = c.Message
+		return
+	}
+	c.LastTransitionTime = metav1.Now()
+	s.Conditions = append(s.Conditions, c)
+}
+
+// clusterOperatorVersions reports the operator version and the image tags of
+// the components
+func clusterOperatorVersions(release *operv1.ReleaseConfigDefinition) []configv1.OperandVersion {
+	versions := []configv1.OperandVersion{{Name: "operator", Version: version.Version}}
+	for _, c := range components {
+		if v := imageTag(c.image(release)); len(v) != 0 {
+			versions = append(versions, configv1.OperandVersion{Name: c.name, Version: v})
+		}
+	}
+	return versions
+}
+
+// imageTag returns the tag of an image reference, empty if it has none
+func imageTag(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i != -1 {
+		return name[i+1:]
+	}
+	return ""
+}
+
+// clusterOperatorRelatedObjects points at the operator namespace, the
+// instance and the component daemonsets
+func clusterOperatorRelatedObjects(instance *operv1.NuageCNIConfig) []configv1.ObjectReference {
+	refs := []configv1.ObjectReference{
+		{Group: "", Resource: "namespaces", Name: names.Namespace},
+		{
+			Group:     operv1.GroupVersion.Group,
+			Resource:  "nuagecniconfigs",
+			Namespace: instance.Namespace,
+			Name:      instance.Name,
+		},
+	}
+	for _, c := range components {
+		refs = append(refs, configv1.ObjectReference{
+			Group:     appsv1.GroupName,
+			Resource:  "daemonsets",
+			Namespace: names.Namespace,
+			Name:      c.name,
+		})
+	}
+	return refs
+}
+
+// daemonSetsWatch is a source of the daemonsets in the operator namespace
+func (r *NuageCNIConfigReconciler) daemonSetsWatch() cache.SharedIndexInformer {
+	lw := cache.NewListWatchFromClient(r.clientset.AppsV1().RESTClient(), "daemonsets",
+		names.Namespace, fields.Everything())
+	return cache.NewSharedIndexInformer(lw, &appsv1.DaemonSet{}, 0, cache.Indexers{})
+}
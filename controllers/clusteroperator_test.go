@@ -0,0 +1,176 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	"github.com/nuagenetworks/nuage-network-operator/version"
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func componentDaemonSet(name string, desired, updated, available int32) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: names.Namespace, Generation: 1},
+		Status: appsv1.DaemonSetStatus{
+			ObservedGeneration:     1,
+			DesiredNumberScheduled: desired,
+			UpdatedNumberScheduled: updated,
+			NumberAvailable:        available,
+			NumberUnavailable:      desired - available,
+		},
+	}
+}
+
+func findClusterOperatorCondition(conditions []configv1.ClusterOperatorStatusCondition, t configv1.ClusterStatusConditionType) *configv1.ClusterOperatorStatusCondition {
+	for i := range conditions {
+		if conditions[i].Type == t {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestClusterOperatorConditions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	instance := &operv1.NuageCNIConfig{}
+	daemonSets := map[string]*appsv1.DaemonSet{}
+	for _, c := range components {
+		daemonSets[c.name] = componentDaemonSet(c.name, 3, 3, 3)
+	}
+
+	conditions := clusterOperatorConditions(instance, operv1.ManagementStateManaged, daemonSets)
+	g.Expect(findClusterOperatorCondition(conditions, configv1.OperatorAvailable).Status).To(Equal(configv1.ConditionTrue))
+	g.Expect(findClusterOperatorCondition(conditions, configv1.OperatorProgressing).Status).To(Equal(configv1.ConditionFalse))
+	g.Expect(findClusterOperatorCondition(conditions, configv1.OperatorDegraded).Status).To(Equal(configv1.ConditionFalse))
+	g.Expect(findClusterOperatorCondition(conditions, configv1.OperatorUpgradeable).Status).To(Equal(configv1.ConditionTrue))
+
+	daemonSets["nuage-cni"] = componentDaemonSet("nuage-cni", 3, 1, 2)
+	daemonSets["nuage-vrs"] = componentDaemonSet("nuage-vrs", 3, 0, 0)
+	conditions = clusterOperatorConditions(instance, operv1.ManagementStateManaged, daemonSets)
+	available := findClusterOperatorCondition(conditions, configv1.OperatorAvailable)
+	g.Expect(available.Status).To(Equal(configv1.ConditionFalse))
+	g.Expect(available.Reason).To(Equal(ReasonUnavailable))
+	g.Expect(available.Message).To(ContainSubstring("nuage-vrs has no available pods"))
+	g.Expect(available.Message).NotTo(ContainSubstring("nuage-cni"))
+	progressing := findClusterOperatorCondition(conditions, configv1.OperatorProgressing)
+	g.Expect(progressing.Status).To(Equal(configv1.ConditionTrue))
+	g.Expect(progressing.Reason).To(Equal(ReasonRollingOut))
+	g.Expect(progressing.Message).To(ContainSubstring("nuage-cni is rolling out, 1 of 3 pods updated"))
+
+	delete(daemonSets, "nuage-infra")
+	SetCondition(&instance.Status, operv1.ConditionDegraded, corev1.ConditionTrue, "VSDUnreachable", "vsd is down")
+	conditions = clusterOperatorConditions(instance, operv1.ManagementStateUnmanaged, daemonSets)
+	g.Expect(findClusterOperatorCondition(conditions, configv1.OperatorProgressing).Message).To(ContainSubstring("nuage-infra is not created"))
+	degraded := findClusterOperatorCondition(conditions, configv1.OperatorDegraded)
+	g.Expect(degraded.Status).To(Equal(configv1.ConditionTrue))
+	g.Expect(degraded.Reason).To(Equal("VSDUnreachable"))
+	g.Expect(degraded.Message).To(Equal("vsd is down"))
+	upgradeable := findClusterOperatorCondition(conditions, configv1.OperatorUpgradeable)
+	g.Expect(upgradeable.Status).To(Equal(configv1.ConditionFalse))
+	g.Expect(upgradeable.Reason).To(Equal(ReasonUnmanaged))
+
+	conditions = clusterOperatorConditions(instance, operv1.ManagementStateRemoved, daemonSets)
+	available = findClusterOperatorCondition(conditions, configv1.OperatorAvailable)
+	g.Expect(available.Status).To(Equal(configv1.ConditionFalse))
+	g.Expect(available.Reason).To(Equal(ReasonRemoved))
+	g.Expect(findClusterOperatorCondition(conditions, configv1.OperatorProgressing).Status).To(Equal(configv1.ConditionTrue))
+	g.Expect(findClusterOperatorCondition(conditions, configv1.OperatorUpgradeable).Status).To(Equal(configv1.ConditionTrue))
+}
+
+func TestImageTag(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(imageTag("registry.domain.tld/nuage/vrs:20.10.2-106")).To(Equal("20.10.2-106"))
+	g.Expect(imageTag("registry.domain.tld:5000/nuage/cni")).To(Equal(""))
+	g.Expect(imageTag("nuage/cni:20.10.2@sha256:abcd")).To(Equal("20.10.2"))
+}
+
+func TestUpdateClusterOperator(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	instance := &operv1.NuageCNIConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nuage-network"},
+		Spec: operv1.NuageCNIConfigSpec{
+			ReleaseConfig: operv1.ReleaseConfigDefinition{
+				VRSTag:     "registry.domain.tld/nuage/vrs:20.10.2-106",
+				CNITag:     "registry.domain.tld/nuage/cni:20.10.2-108",
+				MonitorTag: "registry.domain.tld/nuage/monitor:20.10.2-108",
+				InfraTag:   "registry.domain.tld/nuage/infra",
+			},
+		},
+	}
+	objs := []runtime.Object{}
+	for _, c := range components {
+		objs = append(objs, componentDaemonSet(c.name, 2, 2, 2))
+	}
+	r := &NuageCNIConfigReconciler{
+		Client:       fake.NewFakeClient(),
+		clientset:    kubefake.NewSimpleClientset(objs...),
+		orchestrator: OrchestratorKubernetes,
+	}
+
+	//nothing is published outside of openshift
+	g.Expect(r.UpdateClusterOperator(instance, operv1.ManagementStateManaged)).To(Succeed())
+	co := &configv1.ClusterOperator{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterOperatorName}, co)
+	g.Expect(err).To(HaveOccurred())
+
+	r.orchestrator = OrchestratorOpenShift
+	g.Expect(r.UpdateClusterOperator(instance, operv1.ManagementStateManaged)).To(Succeed())
+	g.Expect(r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterOperatorName}, co)).To(Succeed())
+	g.Expect(co.Status.Conditions).To(HaveLen(4))
+	available := findClusterOperatorCondition(co.Status.Conditions, configv1.OperatorAvailable)
+	g.Expect(available.Status).To(Equal(configv1.ConditionTrue))
+	g.Expect(co.Status.Versions).To(Equal([]configv1.OperandVersion{
+		{Name: "operator", Version: version.Version},
+		{Name: "nuage-vrs", Version: "20.10.2-106"},
+		{Name: "nuage-cni", Version: "20.10.2-108"},
+		{Name: names.NuageMonitor, Version: "20.10.2-108"},
+	}))
+	g.Expect(co.Status.RelatedObjects).To(ContainElement(configv1.ObjectReference{Resource: "namespaces", Name: names.Namespace}))
+	g.Expect(co.Status.RelatedObjects).To(ContainElement(configv1.ObjectReference{
+		Group: operv1.GroupVersion.Group, Resource: "nuagecniconfigs", Name: "nuage-network",
+	}))
+	g.Expect(co.Status.RelatedObjects).To(ContainElement(configv1.ObjectReference{
+		Group: "apps", Resource: "daemonsets", Namespace: names.Namespace, Name: "nuage-vrs",
+	}))
+
+	//the transition time is kept while the status does not change
+	transition := available.LastTransitionTime
+	g.Expect(r.clientset.AppsV1().DaemonSets(names.Namespace).Delete(context.TODO(), "nuage-vrs", metav1.DeleteOptions{})).To(Succeed())
+	g.Expect(r.UpdateClusterOperator(instance, operv1.ManagementStateManaged)).To(Succeed())
+	g.Expect(r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterOperatorName}, co)).To(Succeed())
+	g.Expect(findClusterOperatorCondition(co.Status.Conditions, configv1.OperatorAvailable).Status).To(Equal(configv1.ConditionFalse))
+	degraded := findClusterOperatorCondition(co.Status.Conditions, configv1.OperatorDegraded)
+	g.Expect(degraded.Status).To(Equal(configv1.ConditionFalse))
+	g.Expect(degraded.LastTransitionTime).To(Equal(transition))
+
+	//the cluster operator is kept while the deleted instance is torn down
+	now := metav1.Now()
+	instance.SetDeletionTimestamp(&now)
+	instance.Status.Deletion = &operv1.DeletionStatus{Phase: operv1.DeletionPhaseVRS}
+	g.Expect(r.UpdateClusterOperator(instance, operv1.ManagementStateManaged)).To(Succeed())
+	g.Expect(r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterOperatorName}, co)).To(Succeed())
+
+	instance.Status.Deletion.Phase = operv1.DeletionPhaseDone
+	g.Expect(r.UpdateClusterOperator(instance, operv1.ManagementStateManaged)).To(Succeed())
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: ClusterOperatorName}, co)
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	g.Expect(r.UpdateClusterOperator(instance, operv1.ManagementStateManaged)).To(Succeed())
+}
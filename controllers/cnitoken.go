@@ -0,0 +1,41 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//CNITokenSecret is the service account token secret rendered for
+//rbac.legacyTokenEnv
+const CNITokenSecret = "nuage-cni"
+
+//DeleteCNITokenSecret removes the service account token secret of the cni
+//when rbac.legacyTokenEnv is not set. Its token never expires, so it is not
+//left behind once older cni images no longer need it
+func (r *NuageCNIConfigReconciler) DeleteCNITokenSecret() error {
+	s, err := r.clientset.CoreV1().Secrets(names.Namespace).Get(context.TODO(), CNITokenSecret, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if s.Type != corev1.SecretTypeServiceAccountToken {
+		return nil
+	}
+
+	log.Infof("Deleting secret %s/%s", names.Namespace, CNITokenSecret)
+	err = r.clientset.CoreV1().Secrets(names.Namespace).Delete(context.TODO(), CNITokenSecret, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDeleteCNITokenSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{clientset: fake.NewSimpleClientset()}
+	g.Expect(r.DeleteCNITokenSecret()).To(Succeed())
+
+	token := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: CNITokenSecret, Namespace: names.Namespace},
+		Type:       corev1.SecretTypeServiceAccountToken,
+	}
+	r.clientset = fake.NewSimpleClientset(token)
+	g.Expect(r.DeleteCNITokenSecret()).To(Succeed())
+	_, err := r.clientset.CoreV1().Secrets(names.Namespace).Get(context.TODO(), CNITokenSecret, metav1.GetOptions{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	//a secret of the same name created by the user is kept
+	opaque := token.DeepCopy()
+	opaque.Type = corev1.SecretTypeOpaque
+	r.clientset = fake.NewSimpleClientset(opaque)
+	g.Expect(r.DeleteCNITokenSecret()).To(Succeed())
+	_, err = r.clientset.CoreV1().Secrets(names.Namespace).Get(context.TODO(), CNITokenSecret, metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	//ConfigHashAnnotation holds the hash of the configmaps consumed by a pod template
+	ConfigHashAnnotation = "operator.nuage.io/config-hash"
+	//SkipConfigHashAnnotation keeps a configmap out of the config hash. Its
+	//changes are followed by the pods without a restart
+	SkipConfigHashAnnotation = "operator.nuage.io/skip-config-hash"
+)
+
+//SetConfigHashAnnotations annotates the pod template of every rendered
+//daemonset with the hash of the rendered configmaps it consumes. A config
+//change then changes the pod template and the daemonset rolls its pods
+func SetConfigHashAnnotations(objs []*unstructured.Unstructured) error {
+	configMaps := map[string]*unstructured.Unstructured{}
+	for _, obj := range objs {
+		if obj.GetKind() == "ConfigMap" && obj.GetAnnotations()[SkipConfigHashAnnotation] != "true" {
+			configMaps[obj.GetNamespace()+"/"+obj.GetName()] = obj
+		}
+	}
+
+	for _, obj := range objs {
+		if obj.GetKind() != "DaemonSet" {
+			continue
+		}
+
+		ds := &appsv1.DaemonSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, ds); err != nil {
+			return fmt.Errorf("converting daemonset %s failed %v", obj.GetName(), err)
+		}
+
+		refs := configMapRefs(&ds.Spec.Template.Spec)
+		if len(refs) == 0 {
+			continue
+		}
+
+		h := sha256.New()
+		for _, name := range refs {
+			cm, ok := configMaps[obj.GetNamespace()+"/"+name]
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(cm.Object["data"])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s=%s\n", name, data)
+		}
+
+		if err := unstructured.SetNestedField(obj.Object, fmt.Sprintf("%x", h.Sum(nil)),
+			"spec", "template", "metadata", "annotations", ConfigHashAnnotation); err != nil {
+			return fmt.Errorf("annotating daemonset %s failed %v", obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// configMapRefs returns the sorted names of the configmaps used by the pod
+func configMapRefs(spec *corev1.PodSpec) []string {
+	refSet := map[string]bool{}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				refSet[env.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+		}
+		for _, env := range c.EnvFrom {
+			if env.ConfigMapRef != nil {
+				refSet[env.ConfigMapRef.Name] = true
+			}
+		}
+	}
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			refSet[v.ConfigMap.Name] = true
+		}
+	}
+
+	refs := []string{}
+	for name := range refSet {
+		refs = append(refs, name)
+	}
+	sort.Strings(refs)
+	return refs
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func configHashObjects(logLevel string) []*unstructured.Unstructured {
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "nuage-cni-config-data", "namespace": "ns"},
+		"data":       map[string]interface{}{"cni_yaml_config": "loglevel: " + logLevel},
+	}}
+	withRef := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata":   map[string]interface{}{"name": "nuage-cni", "namespace": "ns"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "nuage-cni",
+							"env": []interface{}{
+								map[string]interface{}{
+									"name": "NUAGE_CNI_YAML_CONFIG",
+									"valueFrom": map[string]interface{}{
+										"configMapKeyRef": map[string]interface{}{
+											"name": "nuage-cni-config-data",
+											"key":  "cni_yaml_config",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+	withoutRef := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata":   map[string]interface{}{"name": "nuage-vrs", "namespace": "ns"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "nuage-vrs"},
+					},
+				},
+			},
+		},
+	}}
+	return []*unstructured.Unstructured{cm, withRef, withoutRef}
+}
+
+func TestSetConfigHashAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	objs := configHashObjects("info")
+	g.Expect(SetConfigHashAnnotations(objs)).To(Succeed())
+	hash, found, err := unstructured.NestedString(objs[1].Object, "spec", "template", "metadata", "annotations", ConfigHashAnnotation)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(found).To(BeTrue())
+	g.Expect(hash).ToNot(BeEmpty())
+
+	_, found, _ = unstructured.NestedString(objs[2].Object, "spec", "template", "metadata", "annotations", ConfigHashAnnotation)
+	g.Expect(found).To(BeFalse())
+
+	same := configHashObjects("info")
+	g.Expect(SetConfigHashAnnotations(same)).To(Succeed())
+	sameHash, _, _ := unstructured.NestedString(same[1].Object, "spec", "template", "metadata", "annotations", ConfigHashAnnotation)
+	g.Expect(sameHash).To(Equal(hash))
+
+	changed := configHashObjects("debug")
+	g.Expect(SetConfigHashAnnotations(changed)).To(Succeed())
+	changedHash, _, _ := unstructured.NestedString(changed[1].Object, "spec", "template", "metadata", "annotations", ConfigHashAnnotation)
+	g.Expect(changedHash).ToNot(Equal(hash))
+}
+
+func TestSetConfigHashAnnotationsSkip(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	hashOf := func(logLevel string) string {
+		objs := configHashObjects(logLevel)
+		objs[0].SetAnnotations(map[string]string{SkipConfigHashAnnotation: "true"})
+		g.Expect(SetConfigHashAnnotations(objs)).To(Succeed())
+		hash, _, _ := unstructured.NestedString(objs[1].Object, "spec", "template", "metadata", "annotations", ConfigHashAnnotation)
+		return hash
+	}
+
+	//changes of a skipped configmap do not roll the pods
+	g.Expect(hashOf("debug")).To(Equal(hashOf("info")))
+}
@@ -21,11 +21,6 @@ var certConfig = types.NamespacedName{
 	Name:      names.NuageCertConfig,
 }
 
-var monitConfig = types.NamespacedName{
-	Namespace: names.Namespace,
-	Name:      names.NuageMonitorConfig,
-}
-
 var releaseConfig = types.NamespacedName{
 	Namespace: names.Namespace,
 	Name:      names.NuageReleaseConfig,
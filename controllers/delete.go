@@ -6,11 +6,21 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -38,3 +48,268 @@ func (r *NuageCNIConfigReconciler) DeleteResource(nsn types.NamespacedName, obj
 	}
 	return err
 }
+
+const (
+	//DefaultDeletionTimeout is the time given to the pods of a component to terminate
+	DefaultDeletionTimeout = 5 * time.Minute
+	//ReasonDeletionTimedOut is reported when pods did not terminate in time
+	ReasonDeletionTimedOut = "DeletionTimedOut"
+
+	deletionPollInterval = 5 * time.Second
+)
+
+// deletionPhases lists the components in the order they are torn down
+var deletionPhases = []struct {
+	phase operv1.DeletionPhase
+	name  string
+}{
+	{operv1.DeletionPhaseInfra, "nuage-infra"},
+	{operv1.DeletionPhaseMonitor, names.NuageMonitor},
+	{operv1.DeletionPhaseCNI, "nuage-cni"},
+	{operv1.DeletionPhaseVRS, "nuage-vrs"},
+	{operv1.DeletionPhaseCleanup, names.NuageCleanup},
+}
+
+//ReconcileDeletion tears down the nuage components and removes the
+//finalizer once all of them are gone
+func (r *NuageCNIConfigReconciler) ReconcileDeletion(instance *operv1.NuageCNIConfig, objs []*unstructured.Unstructured) (ctrl.Result, error) {
+	if res, done, err := r.teardown(instance, objs); !done {
+		return res, err
+	}
+
+	// Remove nuageFinalizer.
+	instance.SetFinalizers(nil)
+	if err := r.Client.Update(context.TODO(), instance); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+//ReconcileRemoval tears down the nuage components of an instance with
+//management state Removed. The instance and its finalizer are kept
+func (r *NuageCNIConfigReconciler) ReconcileRemoval(instance *operv1.NuageCNIConfig, objs []*unstructured.Unstructured) (ctrl.Result, error) {
+	res, done, err := r.teardown(instance, objs)
+	if done {
+		log.Infof("Nuage components removed")
+	}
+	return res, err
+}
+
+//ClearDeletionStatus forgets a previous teardown, so that the next one
+//starts from the first phase
+func (r *NuageCNIConfigReconciler) ClearDeletionStatus(instance *operv1.NuageCNIConfig) error {
+	if instance.Status.Deletion == nil {
+		return nil
+	}
+	instance.Status.Deletion = nil
+	return r.UpdateStatus(instance)
+}
+
+// teardown removes the nuage components one phase at a time and records the
+// phase in status. While pods of a phase are terminating it asks to be
+// requeued and returns done once all phases are finished
+func (r *NuageCNIConfigReconciler) teardown(instance *operv1.NuageCNIConfig, objs []*unstructured.Unstructured) (ctrl.Result, bool, error) {
+	timeout := DefaultDeletionTimeout
+	if instance.Spec.DeletionConfig.Timeout != nil {
+		timeout = instance.Spec.DeletionConfig.Timeout.Duration
+	}
+
+	for _, p := range deletionPhases[currentDeletionPhase(instance.Status.Deletion):] {
+		cleanup := p.phase == operv1.DeletionPhaseCleanup
+		if cleanup && instance.Spec.DeletionPolicy != operv1.DeletionPolicyCleanupHost {
+			continue
+		}
+
+		if err := r.setDeletionPhase(instance, p.phase, ""); err != nil {
+			log.Errorf("updating deletion status failed %v", err)
+			return ctrl.Result{}, false, err
+		}
+
+		var pending int
+		var err error
+		if cleanup {
+			pending, err = r.runHostCleanup(objs)
+		} else {
+			pending, err = r.deleteComponent(objs, p.name)
+		}
+		if err != nil {
+			return ctrl.Result{}, false, err
+		}
+		if pending == 0 {
+			log.Infof("Finished deletion phase %s", p.phase)
+			continue
+		}
+
+		elapsed := time.Since(instance.Status.Deletion.PhaseStartTime.Time)
+		if elapsed < timeout {
+			log.Infof("Waiting for %d pods of %s", pending, p.name)
+			return ctrl.Result{RequeueAfter: deletionPollInterval}, false, nil
+		}
+
+		if !instance.Spec.DeletionConfig.ForceDelete {
+			msg := fmt.Sprintf("%d pods of %s not deleted after %s", pending, p.name, timeout)
+			if cleanup {
+				msg = fmt.Sprintf("cleanup not finished on %d nodes after %s", pending, timeout)
+			}
+			log.Errorf("%s", msg)
+			if err := r.setDeletionPhase(instance, p.phase, msg); err != nil {
+				return ctrl.Result{}, false, err
+			}
+			return ctrl.Result{RequeueAfter: deletionPollInterval}, false, r.SetDegraded(instance, ReasonDeletionTimedOut, msg)
+		}
+
+		if cleanup {
+			log.Warnf("Giving up on cleanup of %d nodes after %s", pending, timeout)
+			if err := r.deleteNuageResourceByName(objs, p.name); err != nil {
+				return ctrl.Result{}, false, err
+			}
+			continue
+		}
+
+		log.Infof("Force deleting %d pods of %s after %s", pending, p.name, timeout)
+		pods, err := r.listComponentPods(p.name)
+		if err != nil {
+			return ctrl.Result{}, false, err
+		}
+		if err := r.forceDeletePods(pods); err != nil {
+			log.Errorf("force deleting pods of %s failed %v", p.name, err)
+			return ctrl.Result{}, false, err
+		}
+		return ctrl.Result{RequeueAfter: deletionPollInterval}, false, nil
+	}
+
+	if err := r.setDeletionPhase(instance, operv1.DeletionPhaseDone, ""); err != nil {
+		log.Errorf("updating deletion status failed %v", err)
+		return ctrl.Result{}, false, err
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// deleteComponent deletes the objects of the component and returns the
+// number of its pods that are still present
+func (r *NuageCNIConfigReconciler) deleteComponent(objs []*unstructured.Unstructured, name string) (int, error) {
+	if err := r.deleteNuageResourceByName(objs, name); err != nil {
+		return 0, err
+	}
+	if name == names.NuageMonitor {
+		if err := r.DeleteMonitorService(); err != nil {
+			log.Errorf("deleting the monitor service failed %v", err)
+			return 0, err
+		}
+	}
+
+	pods, err := r.listComponentPods(name)
+	if err != nil {
+		log.Errorf("listing pods of %s failed %v", name, err)
+		return 0, err
+	}
+	return len(pods), nil
+}
+
+// runHostCleanup applies the cleanup daemonset and returns the number of
+// nodes on which it has not finished yet. The daemonset is removed once the
+// cleanup is done on all nodes
+func (r *NuageCNIConfigReconciler) runHostCleanup(objs []*unstructured.Unstructured) (int, error) {
+	for _, obj := range objs {
+		if obj.GetName() != names.NuageCleanup {
+			continue
+		}
+		if err := r.ApplyObject(types.NamespacedName{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		}, obj); err != nil {
+			log.Errorf("Applying cleanup object %s type %s failed %v", obj.GetName(), obj.GroupVersionKind(), err)
+			return 0, err
+		}
+	}
+
+	ds := &appsv1.DaemonSet{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{
+		Name:      names.NuageCleanup,
+		Namespace: names.Namespace,
+	}, ds); err != nil {
+		log.Errorf("getting cleanup daemonset failed %v", err)
+		return 0, err
+	}
+
+	if pending := pendingDaemonSetPods(ds); pending != 0 {
+		return pending, nil
+	}
+
+	return 0, r.deleteNuageResourceByName(objs, names.NuageCleanup)
+}
+
+// pendingDaemonSetPods returns the number of nodes on which the daemonset
+// pod is not ready yet
+func pendingDaemonSetPods(ds *appsv1.DaemonSet) int {
+	desired := int(ds.Status.DesiredNumberScheduled)
+	if ds.Status.ObservedGeneration < ds.Generation {
+		if desired == 0 {
+			return 1
+		}
+		return desired
+	}
+
+	done := int(ds.Status.NumberReady)
+	if updated := int(ds.Status.UpdatedNumberScheduled); updated < done {
+		done = updated
+	}
+	return desired - done
+}
+
+// currentDeletionPhase returns the index of the phase to resume from
+func currentDeletionPhase(s *operv1.DeletionStatus) int {
+	if s == nil {
+		return 0
+	}
+	if s.Phase == operv1.DeletionPhaseDone {
+		return len(deletionPhases)
+	}
+	for i, p := range deletionPhases {
+		if p.phase == s.Phase {
+			return i
+		}
+	}
+	return 0
+}
+
+// setDeletionPhase saves the phase in status, the start time is reset only
+// when the phase changes
+func (r *NuageCNIConfigReconciler) setDeletionPhase(instance *operv1.NuageCNIConfig, phase operv1.DeletionPhase, message string) error {
+	s := instance.Status.Deletion
+	if s != nil && s.Phase == phase && s.Message == message {
+		return nil
+	}
+	if s == nil || s.Phase != phase {
+		s = &operv1.DeletionStatus{
+			Phase:          phase,
+			PhaseStartTime: metav1.Now(),
+		}
+	}
+	s.Message = message
+	instance.Status.Deletion = s
+	return r.UpdateStatus(instance)
+}
+
+func (r *NuageCNIConfigReconciler) listComponentPods(name string) ([]v1.Pod, error) {
+	pods, err := r.clientset.CoreV1().Pods(names.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "k8s-app=" + name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func (r *NuageCNIConfigReconciler) forceDeletePods(pods []v1.Pod) error {
+	var gracePeriod int64
+	for _, pod := range pods {
+		err := r.clientset.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{
+			GracePeriodSeconds: &gracePeriod,
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func componentPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-abcde",
+			Namespace: names.Namespace,
+			Labels:    map[string]string{"k8s-app": name},
+		},
+	}
+}
+
+func TestReconcileDeletion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(operv1.AddToScheme(s)).To(Succeed())
+
+	now := metav1.Now()
+	instance := &operv1.NuageCNIConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "nuage",
+			Finalizers:        []string{nuageFinalizer},
+			DeletionTimestamp: &now,
+		},
+	}
+
+	r := &NuageCNIConfigReconciler{
+		Client: fake.NewFakeClientWithScheme(s, instance),
+		clientset: k8sfake.NewSimpleClientset(componentPod("nuage-infra"), componentPod("nuage-cni"), &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: names.NuageMonitor, Namespace: names.Namespace},
+		}),
+	}
+
+	res, err := r.ReconcileDeletion(instance, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(deletionPollInterval))
+	g.Expect(instance.Status.Deletion.Phase).To(Equal(operv1.DeletionPhaseInfra))
+
+	instance.Status.Deletion.PhaseStartTime = metav1.NewTime(time.Now().Add(-2 * DefaultDeletionTimeout))
+	res, err = r.ReconcileDeletion(instance, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(deletionPollInterval))
+	g.Expect(instance.Status.Deletion.Message).To(ContainSubstring("1 pods of nuage-infra not deleted"))
+	c := GetCondition(&instance.Status, operv1.ConditionDegraded)
+	g.Expect(c).ToNot(BeNil())
+	g.Expect(c.Reason).To(Equal(ReasonDeletionTimedOut))
+
+	instance.Spec.DeletionConfig.ForceDelete = true
+	instance.Spec.DeletionConfig.Timeout = &metav1.Duration{Duration: time.Minute}
+	res, err = r.ReconcileDeletion(instance, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(deletionPollInterval))
+	pods, err := r.listComponentPods("nuage-infra")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pods).To(BeEmpty())
+
+	res, err = r.ReconcileDeletion(instance, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(instance.Status.Deletion.Phase).To(Equal(operv1.DeletionPhaseCNI))
+	//the monitor service is not rendered and removed with the monitor
+	_, err = r.clientset.CoreV1().Services(names.Namespace).Get(context.TODO(), names.NuageMonitor, metav1.GetOptions{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	g.Expect(r.clientset.CoreV1().Pods(names.Namespace).Delete(context.TODO(), "nuage-cni-abcde", metav1.DeleteOptions{})).To(Succeed())
+	res, err = r.ReconcileDeletion(instance, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeZero())
+	g.Expect(instance.Status.Deletion.Phase).To(Equal(operv1.DeletionPhaseDone))
+
+	saved := &operv1.NuageCNIConfig{}
+	g.Expect(r.Client.Get(context.TODO(), types.NamespacedName{Name: "nuage"}, saved)).To(Succeed())
+	g.Expect(saved.GetFinalizers()).To(BeEmpty())
+}
+
+func TestReconcileDeletionHostCleanup(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+	g.Expect(operv1.AddToScheme(s)).To(Succeed())
+
+	now := metav1.Now()
+	newInstance := func(policy operv1.DeletionPolicy) *operv1.NuageCNIConfig {
+		return &operv1.NuageCNIConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "nuage",
+				Finalizers:        []string{nuageFinalizer},
+				DeletionTimestamp: &now,
+			},
+			Spec: operv1.NuageCNIConfigSpec{DeletionPolicy: policy},
+		}
+	}
+	cleanup := &unstructured.Unstructured{}
+	cleanup.SetAPIVersion("apps/v1")
+	cleanup.SetKind("DaemonSet")
+	cleanup.SetName(names.NuageCleanup)
+	cleanup.SetNamespace(names.Namespace)
+	dsName := types.NamespacedName{Name: names.NuageCleanup, Namespace: names.Namespace}
+
+	instance := newInstance(operv1.DeletionPolicyRetain)
+	r := &NuageCNIConfigReconciler{
+		Client:    fake.NewFakeClientWithScheme(s, instance),
+		clientset: k8sfake.NewSimpleClientset(),
+	}
+	_, err := r.ReconcileDeletion(instance, []*unstructured.Unstructured{cleanup})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(instance.Status.Deletion.Phase).To(Equal(operv1.DeletionPhaseDone))
+	err = r.Client.Get(context.TODO(), dsName, &appsv1.DaemonSet{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	//the fake client keeps the status of the applied daemonset, it stands in
+	//for the one reported by the daemonset controller
+	setCleanupStatus := func(ready int64) {
+		g.Expect(unstructured.SetNestedField(cleanup.Object, map[string]interface{}{
+			"observedGeneration":     int64(1),
+			"desiredNumberScheduled": int64(2),
+			"updatedNumberScheduled": ready,
+			"numberReady":            ready,
+		}, "status")).To(Succeed())
+	}
+	cleanup.SetGeneration(1)
+	setCleanupStatus(1)
+
+	//one node has not run the cleanup yet
+	instance = newInstance(operv1.DeletionPolicyCleanupHost)
+	r.Client = fake.NewFakeClientWithScheme(s, instance)
+	res, err := r.ReconcileDeletion(instance, []*unstructured.Unstructured{cleanup})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(Equal(deletionPollInterval))
+	g.Expect(instance.Status.Deletion.Phase).To(Equal(operv1.DeletionPhaseCleanup))
+	g.Expect(instance.GetFinalizers()).To(ContainElement(nuageFinalizer))
+	g.Expect(r.Client.Get(context.TODO(), dsName, &appsv1.DaemonSet{})).To(Succeed())
+
+	setCleanupStatus(2)
+	res, err = r.ReconcileDeletion(instance, []*unstructured.Unstructured{cleanup})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeZero())
+	g.Expect(instance.Status.Deletion.Phase).To(Equal(operv1.DeletionPhaseDone))
+	g.Expect(instance.GetFinalizers()).To(BeEmpty())
+	err = r.Client.Get(context.TODO(), dsName, &appsv1.DaemonSet{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+}
+
+func TestPendingDaemonSetPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ds := &appsv1.DaemonSet{}
+	ds.Generation = 1
+	g.Expect(pendingDaemonSetPods(ds)).To(Equal(1))
+
+	ds.Status.ObservedGeneration = 1
+	ds.Status.DesiredNumberScheduled = 3
+	ds.Status.UpdatedNumberScheduled = 3
+	ds.Status.NumberReady = 1
+	g.Expect(pendingDaemonSetPods(ds)).To(Equal(2))
+
+	ds.Status.NumberReady = 3
+	ds.Status.UpdatedNumberScheduled = 2
+	g.Expect(pendingDaemonSetPods(ds)).To(Equal(1))
+
+	ds.Status.UpdatedNumberScheduled = 3
+	g.Expect(pendingDaemonSetPods(ds)).To(Equal(0))
+}
+
+func TestReconcileRemoval(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(operv1.AddToScheme(s)).To(Succeed())
+
+	instance := &operv1.NuageCNIConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "nuage",
+			Finalizers: []string{nuageFinalizer},
+		},
+		Spec: operv1.NuageCNIConfigSpec{ManagementState: operv1.ManagementStateRemoved},
+	}
+	r := &NuageCNIConfigReconciler{
+		Client:    fake.NewFakeClientWithScheme(s, instance),
+		clientset: k8sfake.NewSimpleClientset(),
+	}
+
+	res, err := r.ReconcileRemoval(instance, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeZero())
+	g.Expect(instance.Status.Deletion.Phase).To(Equal(operv1.DeletionPhaseDone))
+
+	saved := &operv1.NuageCNIConfig{}
+	g.Expect(r.Client.Get(context.TODO(), types.NamespacedName{Name: "nuage"}, saved)).To(Succeed())
+	g.Expect(saved.GetFinalizers()).To(ConsistOf(nuageFinalizer))
+
+	g.Expect(r.ClearDeletionStatus(instance)).To(Succeed())
+	g.Expect(instance.Status.Deletion).To(BeNil())
+}
+
+func TestGetManagementState(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	instance := &operv1.NuageCNIConfig{}
+	g.Expect(getManagementState(instance)).To(Equal(operv1.ManagementStateManaged))
+
+	instance.Spec.ManagementState = operv1.ManagementStateRemoved
+	g.Expect(getManagementState(instance)).To(Equal(operv1.ManagementStateRemoved))
+
+	instance.SetAnnotations(map[string]string{PausedAnnotation: "false"})
+	g.Expect(getManagementState(instance)).To(Equal(operv1.ManagementStateRemoved))
+
+	instance.SetAnnotations(map[string]string{PausedAnnotation: "true"})
+	g.Expect(getManagementState(instance)).To(Equal(operv1.ManagementStateUnmanaged))
+}
+
+func TestIsReconcileHeld(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	instance := &operv1.NuageCNIConfig{}
+	g.Expect(isReconcileHeld(instance, operv1.ManagementStateManaged)).To(BeFalse())
+	g.Expect(isReconcileHeld(instance, operv1.ManagementStateUnmanaged)).To(BeTrue())
+
+	//deleting an unmanaged config tears the components down
+	now := metav1.Now()
+	instance.SetDeletionTimestamp(&now)
+	g.Expect(isReconcileHeld(instance, operv1.ManagementStateUnmanaged)).To(BeFalse())
+
+	//pausing also holds the teardown
+	instance.SetAnnotations(map[string]string{PausedAnnotation: "true"})
+	g.Expect(isReconcileHeld(instance, getManagementState(instance))).To(BeTrue())
+}
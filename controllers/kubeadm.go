@@ -0,0 +1,224 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	//KubeadmConfigMap is the configmap in which kubeadm stores the cluster configuration
+	KubeadmConfigMap = "kubeadm-config"
+
+	kubeSystemNamespace     = "kube-system"
+	kubeadmClusterConfigKey = "ClusterConfiguration"
+
+	flagServiceClusterIPRange = "--service-cluster-ip-range"
+	flagClusterCIDR           = "--cluster-cidr"
+	flagNodeCIDRMaskSize      = "--node-cidr-mask-size"
+
+	kubeadmAPIServerPort = 6443
+)
+
+// kubeadmClusterConfiguration is the subset of the kubeadm
+// ClusterConfiguration that is relevant for the pod network
+type kubeadmClusterConfiguration struct {
+	//a stable dns name or virtual ip of the api servers, optionally with
+	//a port
+	ControlPlaneEndpoint string `json:"controlPlaneEndpoint"`
+	Networking           struct {
+		PodSubnet     string `json:"podSubnet"`
+		ServiceSubnet string `json:"serviceSubnet"`
+	} `json:"networking"`
+	ControllerManager struct {
+		//a map up to v1beta3, a list of name and value since v1beta4
+		ExtraArgs json.RawMessage `json:"extraArgs"`
+	} `json:"controllerManager"`
+}
+
+// kubeadmArg is an extra argument of a control plane component in kubeadm
+// v1beta4 and later
+type kubeadmArg struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// kubeadmExtraArgs decodes the extra arguments of a control plane component
+// in either the map or the list form. Later duplicates of a list win
+func kubeadmExtraArgs(raw json.RawMessage) (map[string]string, error) {
+	args := map[string]string{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return args, nil
+	}
+	if err := json.Unmarshal(raw, &args); err == nil {
+		return args, nil
+	}
+
+	list := []kubeadmArg{}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("extraArgs is neither a map nor a list of name and value")
+	}
+	for _, arg := range list {
+		args[arg.Name] = arg.Value
+	}
+	return args, nil
+}
+
+//DiscoverK8SClusterNetwork reads the pod and service network from the
+//kubeadm-config configmap and falls back to the flags of the control plane
+//static pods. Fields that could not be discovered are left empty
+func (r *NuageCNIConfigReconciler) DiscoverK8SClusterNetwork() (*operv1.ClusterNetworkConfigDefinition, error) {
+	c := &operv1.ClusterNetworkConfigDefinition{}
+
+	if err := r.discoverFromKubeadmConfig(c); err != nil {
+		return nil, err
+	}
+
+	if isClusterNetworkComplete(c) {
+		return c, nil
+	}
+
+	if err := r.discoverFromStaticPods(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// kubeadmClusterConfig reads the kubeadm ClusterConfiguration. It is nil
+// when kubeadm did not store one or it cannot be parsed
+func (r *NuageCNIConfigReconciler) kubeadmClusterConfig() (*kubeadmClusterConfiguration, error) {
+	cm, err := r.clientset.CoreV1().ConfigMaps(kubeSystemNamespace).Get(context.TODO(), KubeadmConfigMap, metav1.GetOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		log.Infof("configmap %s not found in %s", KubeadmConfigMap, kubeSystemNamespace)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, ok := cm.Data[kubeadmClusterConfigKey]
+	if !ok {
+		return nil, nil
+	}
+
+	kc := &kubeadmClusterConfiguration{}
+	if err := yaml.Unmarshal([]byte(data), kc); err != nil {
+		log.Errorf("parsing %s from configmap %s failed %v", kubeadmClusterConfigKey, KubeadmConfigMap, err)
+		return nil, nil
+	}
+	return kc, nil
+}
+
+// discoverControlPlaneEndpoint returns the url of the kubeadm control plane
+// endpoint, empty when none is configured
+func (r *NuageCNIConfigReconciler) discoverControlPlaneEndpoint() (string, error) {
+	kc, err := r.kubeadmClusterConfig()
+	if err != nil || kc == nil || len(kc.ControlPlaneEndpoint) == 0 {
+		return "", err
+	}
+
+	host, port, err := net.SplitHostPort(kc.ControlPlaneEndpoint)
+	if err != nil {
+		//the port is optional
+		return apiServerURL(strings.Trim(kc.ControlPlaneEndpoint, "[]"), kubeadmAPIServerPort), nil
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		log.Errorf("control plane endpoint %q in configmap %s has an invalid port", kc.ControlPlaneEndpoint, KubeadmConfigMap)
+		return "", nil
+	}
+	return apiServerURL(host, int32(p)), nil
+}
+
+func (r *NuageCNIConfigReconciler) discoverFromKubeadmConfig(c *operv1.ClusterNetworkConfigDefinition) error {
+	kc, err := r.kubeadmClusterConfig()
+	if err != nil || kc == nil {
+		return err
+	}
+
+	c.ClusterNetworkCIDR = firstCIDR(kc.Networking.PodSubnet)
+	c.ServiceNetworkCIDR = firstCIDR(kc.Networking.ServiceSubnet)
+	//the networking fields are kept when the extra args cannot be read, the
+	//mask size then falls back to the static pod flags
+	args, err := kubeadmExtraArgs(kc.ControllerManager.ExtraArgs)
+	if err != nil {
+		log.Errorf("parsing the controller manager args from configmap %s failed %v", KubeadmConfigMap, err)
+		return nil
+	}
+	c.ClusterNetworkSubnetLength = parseMaskSize(args[strings.TrimPrefix(flagNodeCIDRMaskSize, "--")])
+	return nil
+}
+
+func (r *NuageCNIConfigReconciler) discoverFromStaticPods(c *operv1.ClusterNetworkConfigDefinition) error {
+	for _, component := range []string{"kube-apiserver", "kube-controller-manager"} {
+		pods, err := r.clientset.CoreV1().Pods(kubeSystemNamespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: "component=" + component,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, pod := range pods.Items {
+			flags := podFlags(&pod)
+			if len(c.ServiceNetworkCIDR) == 0 {
+				c.ServiceNetworkCIDR = firstCIDR(flags[flagServiceClusterIPRange])
+			}
+			if len(c.ClusterNetworkCIDR) == 0 {
+				c.ClusterNetworkCIDR = firstCIDR(flags[flagClusterCIDR])
+			}
+			if c.ClusterNetworkSubnetLength == 0 {
+				c.ClusterNetworkSubnetLength = parseMaskSize(flags[flagNodeCIDRMaskSize])
+			}
+		}
+	}
+	return nil
+}
+
+// podFlags returns the --flag=value arguments of all containers in the pod
+func podFlags(pod *corev1.Pod) map[string]string {
+	flags := map[string]string{}
+	for _, container := range pod.Spec.Containers {
+		args := append(append([]string{}, container.Command...), container.Args...)
+		for _, arg := range args {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) != 2 || !strings.HasPrefix(kv[0], "--") {
+				continue
+			}
+			flags[kv[0]] = kv[1]
+		}
+	}
+	return flags
+}
+
+// firstCIDR returns the first cidr of a dual stack cidr list
+func firstCIDR(s string) string {
+	return strings.TrimSpace(strings.Split(s, ",")[0])
+}
+
+func parseMaskSize(s string) uint32 {
+	size, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(size)
+}
+
+func isClusterNetworkComplete(c *operv1.ClusterNetworkConfigDefinition) bool {
+	return len(c.ClusterNetworkCIDR) != 0 &&
+		len(c.ServiceNetworkCIDR) != 0 &&
+		c.ClusterNetworkSubnetLength != 0
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var kubeadmConfig = &corev1.ConfigMap{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      KubeadmConfigMap,
+		Namespace: kubeSystemNamespace,
+	},
+	Data: map[string]string{
+		kubeadmClusterConfigKey: `
+apiVersion: kubeadm.k8s.io/v1beta2
+kind: ClusterConfiguration
+controllerManager:
+  extraArgs:
+    node-cidr-mask-size: "26"
+networking:
+  dnsDomain: cluster.local
+  podSubnet: 70.70.0.0/16
+  serviceSubnet: 10.96.0.0/12
+`,
+	},
+}
+
+var apiServerPod = &corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "kube-apiserver-master",
+		Namespace: kubeSystemNamespace,
+		Labels:    map[string]string{"component": "kube-apiserver"},
+	},
+	Spec: corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name: "kube-apiserver",
+				Command: []string{
+					"kube-apiserver",
+					"--advertise-address=192.168.1.10",
+					"--service-cluster-ip-range=172.30.0.0/16,fd00::/108",
+				},
+			},
+		},
+	},
+}
+
+var controllerManagerPod = &corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "kube-controller-manager-master",
+		Namespace: kubeSystemNamespace,
+		Labels:    map[string]string{"component": "kube-controller-manager"},
+	},
+	Spec: corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name:    "kube-controller-manager",
+				Command: []string{"kube-controller-manager"},
+				Args:    []string{"--cluster-cidr=80.80.0.0/16", "--allocate-node-cidrs=true"},
+			},
+		},
+	},
+}
+
+func TestDiscoverKubeadmConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		clientset: fake.NewSimpleClientset(kubeadmConfig, apiServerPod),
+	}
+
+	c, err := r.DiscoverK8SClusterNetwork()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.ClusterNetworkCIDR).To(Equal("70.70.0.0/16"))
+	g.Expect(c.ServiceNetworkCIDR).To(Equal("10.96.0.0/12"))
+	g.Expect(c.ClusterNetworkSubnetLength).To(Equal(uint32(26)))
+}
+
+func TestDiscoverKubeadmConfigV1beta4(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	v1beta4 := kubeadmConfig.DeepCopy()
+	v1beta4.Data[kubeadmClusterConfigKey] = `
+apiVersion: kubeadm.k8s.io/v1beta4
+kind: ClusterConfiguration
+controllerManager:
+  extraArgs:
+  - name: bind-address
+    value: 0.0.0.0
+  - name: node-cidr-mask-size
+    value: "25"
+networking:
+  podSubnet: 70.70.0.0/16
+  serviceSubnet: 10.96.0.0/12
+`
+	r := &NuageCNIConfigReconciler{clientset: fake.NewSimpleClientset(v1beta4)}
+	c, err := r.DiscoverK8SClusterNetwork()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.ClusterNetworkCIDR).To(Equal("70.70.0.0/16"))
+	g.Expect(c.ServiceNetworkCIDR).To(Equal("10.96.0.0/12"))
+	g.Expect(c.ClusterNetworkSubnetLength).To(Equal(uint32(25)))
+
+	//unreadable extra args keep the networking fields
+	v1beta4.Data[kubeadmClusterConfigKey] = `
+controllerManager:
+  extraArgs: node-cidr-mask-size
+networking:
+  podSubnet: 70.70.0.0/16
+  serviceSubnet: 10.96.0.0/12
+`
+	r = &NuageCNIConfigReconciler{clientset: fake.NewSimpleClientset(v1beta4, controllerManagerPod)}
+	c, err = r.DiscoverK8SClusterNetwork()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.ClusterNetworkCIDR).To(Equal("70.70.0.0/16"))
+	g.Expect(c.ServiceNetworkCIDR).To(Equal("10.96.0.0/12"))
+	g.Expect(c.ClusterNetworkSubnetLength).To(Equal(uint32(0)))
+}
+
+func TestDiscoverControlPlaneEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{clientset: fake.NewSimpleClientset(kubeadmConfig)}
+	url, err := r.discoverControlPlaneEndpoint()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(url).To(BeEmpty())
+
+	for endpoint, expected := range map[string]string{
+		"api.example.com:8443": "https://api.example.com:8443",
+		"api.example.com":      "https://api.example.com:6443",
+		"[fd00::1]:8443":       "https://[fd00::1]:8443",
+		"fd00::1":              "https://[fd00::1]:6443",
+		"api.example.com:port": "",
+	} {
+		cm := kubeadmConfig.DeepCopy()
+		cm.Data[kubeadmClusterConfigKey] += "controlPlaneEndpoint: \"" + endpoint + "\"\n"
+		r = &NuageCNIConfigReconciler{clientset: fake.NewSimpleClientset(cm)}
+		url, err = r.discoverControlPlaneEndpoint()
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(url).To(Equal(expected), endpoint)
+	}
+}
+
+func TestDiscoverStaticPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		clientset: fake.NewSimpleClientset(apiServerPod, controllerManagerPod),
+	}
+
+	c, err := r.DiscoverK8SClusterNetwork()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.ClusterNetworkCIDR).To(Equal("80.80.0.0/16"))
+	g.Expect(c.ServiceNetworkCIDR).To(Equal("172.30.0.0/16"))
+	g.Expect(c.ClusterNetworkSubnetLength).To(Equal(uint32(0)))
+
+	r = &NuageCNIConfigReconciler{
+		clientset: fake.NewSimpleClientset(),
+	}
+
+	c, err = r.DiscoverK8SClusterNetwork()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(isClusterNetworkComplete(c)).To(BeFalse())
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	//ClusterCAConfigMap is published in every namespace with the api server CA
+	ClusterCAConfigMap = "kube-root-ca.crt"
+	//ReasonClusterCAUnavailable is reported when the api server CA is not known
+	ReasonClusterCAUnavailable = "ClusterCAUnavailable"
+
+	clusterCAKey = "ca.crt"
+)
+
+//GetClusterCA returns the CA of the api server that is written into the
+//generated kubeconfig. It is read from the kube-root-ca.crt configmap of
+//the operator namespace and falls back to the CA the operator itself uses
+func (r *NuageCNIConfigReconciler) GetClusterCA() (string, error) {
+	cm, err := r.clientset.CoreV1().ConfigMaps(names.Namespace).Get(context.TODO(), ClusterCAConfigMap, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", err
+	}
+	if err == nil && len(cm.Data[clusterCAKey]) > 0 {
+		return cm.Data[clusterCAKey], nil
+	}
+
+	if len(r.clusterCA) == 0 {
+		return "", fmt.Errorf("configmap %s/%s has no %s", names.Namespace, ClusterCAConfigMap, clusterCAKey)
+	}
+	return r.clusterCA, nil
+}
+
+// restConfigCA returns the CA data of the rest config
+func restConfigCA(c *rest.Config) (string, error) {
+	if len(c.CAData) > 0 {
+		return string(c.CAData), nil
+	}
+	if len(c.CAFile) == 0 {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestGetClusterCA(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{clientset: fake.NewSimpleClientset()}
+	_, err := r.GetClusterCA()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(ClusterCAConfigMap))
+
+	r.clusterCA = "operator-ca"
+	ca, err := r.GetClusterCA()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(Equal("operator-ca"))
+
+	r.clientset = fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ClusterCAConfigMap,
+			Namespace: names.Namespace,
+		},
+		Data: map[string]string{clusterCAKey: "root-ca"},
+	})
+	ca, err = r.GetClusterCA()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(Equal("root-ca"))
+}
+
+func TestRestConfigCA(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ca, err := restConfigCA(&rest.Config{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(BeEmpty())
+
+	ca, err = restConfigCA(&rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: []byte("data")}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(Equal("data"))
+
+	_, err = restConfigCA(&rest.Config{TLSClientConfig: rest.TLSClientConfig{CAFile: "/nonexistent/ca.crt"}})
+	g.Expect(err).To(HaveOccurred())
+}
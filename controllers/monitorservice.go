@@ -0,0 +1,112 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+//ReconcileMonitorService creates or updates the service fronting the
+//monitor rest servers when it is enabled and removes it otherwise. The
+//monitor pods use the host network, so the endpoints of the service are the
+//master nodes running them. It returns the cluster ip of the service
+func (r *NuageCNIConfigReconciler) ReconcileMonitorService(config *operv1.MonitorConfigDefinition) (string, error) {
+	services := r.clientset.CoreV1().Services(names.Namespace)
+	svc, err := services.Get(context.TODO(), names.NuageMonitor, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", err
+	}
+	found := err == nil
+
+	if !config.Service.Enabled {
+		if found {
+			return "", r.DeleteMonitorService()
+		}
+		return "", nil
+	}
+
+	if !found {
+		svc = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      names.NuageMonitor,
+				Namespace: names.Namespace,
+				Labels:    map[string]string{"k8s-app": names.NuageMonitor},
+			},
+		}
+	}
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	svc.Spec.Selector = map[string]string{"k8s-app": names.NuageMonitor}
+	svc.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "rest",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(config.RestServerPort),
+			TargetPort: intstr.FromInt(config.RestServerPort),
+		},
+	}
+
+	if found {
+		svc, err = services.Update(context.TODO(), svc, metav1.UpdateOptions{})
+	} else {
+		svc, err = services.Create(context.TODO(), svc, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(svc.Spec.ClusterIP) == 0 || svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		return "", fmt.Errorf("service %s/%s has no cluster ip", names.Namespace, names.NuageMonitor)
+	}
+	return svc.Spec.ClusterIP, nil
+}
+
+//DeleteMonitorService removes the service fronting the monitor rest servers.
+//It is not rendered with the other objects, so it is removed explicitly when
+//it is disabled and when the monitor is torn down
+func (r *NuageCNIConfigReconciler) DeleteMonitorService() error {
+	log.Infof("Deleting service %s/%s", names.Namespace, names.NuageMonitor)
+	err := r.clientset.CoreV1().Services(names.Namespace).Delete(context.TODO(), names.NuageMonitor, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// monitorServiceURL is the load balancer url of the monitor service
+func monitorServiceURL(clusterIP string, port int) string {
+	return "https://" + net.JoinHostPort(clusterIP, strconv.Itoa(port))
+}
+
+// monitorHosts returns the names the monitor rest server is reached by,
+// they are added to the certificate
+func monitorHosts(clusterIP, loadBalancerURL string) []string {
+	hosts := []string{}
+	if len(clusterIP) != 0 {
+		hosts = append(hosts,
+			clusterIP,
+			names.NuageMonitor,
+			names.NuageMonitor+"."+names.Namespace,
+			names.NuageMonitor+"."+names.Namespace+".svc",
+		)
+	}
+	if u, err := url.Parse(loadBalancerURL); err == nil && len(u.Hostname()) != 0 {
+		if u.Hostname() != clusterIP {
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	return hosts
+}
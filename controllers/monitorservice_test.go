@@ -0,0 +1,75 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestReconcileMonitorService(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	clientset := fake.NewSimpleClientset()
+	//the fake clientset does not allocate cluster ips
+	clientset.PrependReactor("create", "services", func(a k8stesting.Action) (bool, runtime.Object, error) {
+		svc := a.(k8stesting.CreateAction).GetObject().(*corev1.Service)
+		svc.Spec.ClusterIP = "10.96.0.20"
+		return false, nil, nil
+	})
+	r := &NuageCNIConfigReconciler{clientset: clientset}
+	c := &operv1.MonitorConfigDefinition{RestServerPort: 9443}
+
+	ip, err := r.ReconcileMonitorService(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ip).To(BeEmpty())
+
+	c.Service.Enabled = true
+	ip, err = r.ReconcileMonitorService(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ip).To(Equal("10.96.0.20"))
+
+	svc, err := clientset.CoreV1().Services(names.Namespace).Get(context.TODO(), names.NuageMonitor, metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(svc.Spec.Selector).To(HaveKeyWithValue("k8s-app", names.NuageMonitor))
+	g.Expect(svc.Spec.Ports[0].Port).To(Equal(int32(9443)))
+
+	c.RestServerPort = 9444
+	ip, err = r.ReconcileMonitorService(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ip).To(Equal("10.96.0.20"))
+	svc, _ = clientset.CoreV1().Services(names.Namespace).Get(context.TODO(), names.NuageMonitor, metav1.GetOptions{})
+	g.Expect(svc.Spec.Ports[0].TargetPort.IntValue()).To(Equal(9444))
+
+	c.Service.Enabled = false
+	_, err = r.ReconcileMonitorService(c)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = clientset.CoreV1().Services(names.Namespace).Get(context.TODO(), names.NuageMonitor, metav1.GetOptions{})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestMonitorHosts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(monitorHosts("", "https://lb.example.com:9443/")).To(Equal([]string{"lb.example.com"}))
+
+	url := monitorServiceURL("10.96.0.20", 9443)
+	g.Expect(url).To(Equal("https://10.96.0.20:9443"))
+	g.Expect(monitorHosts("10.96.0.20", url)).To(Equal([]string{
+		"10.96.0.20",
+		"nuage-monitor",
+		"nuage-monitor.nuage-network-operator",
+		"nuage-monitor.nuage-network-operator.svc",
+	}))
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/cni"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	//ReasonClusterNetworkMTUInvalid is reported when the mtu does not fit the uplink
+	ReasonClusterNetworkMTUInvalid = "ClusterNetworkMTUInvalid"
+)
+
+// uplinkMTU reads the mtu of the underlay uplink. The operator runs in
+// host network namespace, so the uplink of its node is visible
+var uplinkMTU = func(name string) (int, error) {
+	intf, err := net.InterfaceByName(name)
+	if err != nil {
+		return 0, err
+	}
+	return intf.MTU, nil
+}
+
+//GetClusterNetworkMTU works out the mtu of the pod interfaces from the
+//underlay uplink mtu and the encapsulation overhead. Only the uplink of the
+//node the operator runs on is read, so auto assumes every node has the same
+//uplink mtu. An uplink that cannot be read fails auto, an explicit mtu is
+//then used without validation
+func (r *NuageCNIConfigReconciler) GetClusterNetworkMTU(spec *operv1.NuageCNIConfigSpec) (int, error) {
+	mtu, err := uplinkMTU(spec.VRSConfig.UnderlayUplink)
+	if err != nil && spec.CNIConfig.MTU.Type == intstr.String {
+		return 0, &ClusterNetworkError{
+			Reason: ReasonClusterNetworkMTUInvalid,
+			Message: fmt.Sprintf("mtu auto cannot read the mtu of uplink %s on the operator node: %v",
+				spec.VRSConfig.UnderlayUplink, err),
+		}
+	} else if err != nil {
+		log.Warnf("reading mtu of uplink %s failed, using mtu %s unvalidated: %v",
+			spec.VRSConfig.UnderlayUplink, spec.CNIConfig.MTU.String(), err)
+		return spec.CNIConfig.MTU.IntValue(), nil
+	}
+
+	effective, err := cni.EffectiveMTU(&spec.CNIConfig, mtu, spec.MonitorConfig.VSDFlags.EncryptionEnabled)
+	if err != nil {
+		return 0, &ClusterNetworkError{
+			Reason:  ReasonClusterNetworkMTUInvalid,
+			Message: err.Error(),
+		}
+	}
+	return effective, nil
+}
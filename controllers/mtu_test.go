@@ -0,0 +1,60 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/cni"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestClusterNetworkMTU(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	orig := uplinkMTU
+	defer func() { uplinkMTU = orig }()
+
+	r := &NuageCNIConfigReconciler{}
+	spec := &operv1.NuageCNIConfigSpec{
+		VRSConfig: operv1.VRSConfigDefinition{UnderlayUplink: "eth0"},
+		CNIConfig: operv1.CNIConfigDefinition{MTU: intstr.FromString(cni.MTUAuto)},
+	}
+
+	uplinkMTU = func(string) (int, error) { return 9000, nil }
+	mtu, err := r.GetClusterNetworkMTU(spec)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mtu).To(Equal(8950))
+
+	spec.MonitorConfig.VSDFlags.EncryptionEnabled = true
+	mtu, err = r.GetClusterNetworkMTU(spec)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mtu).To(Equal(8850))
+
+	uplinkMTU = func(string) (int, error) { return 1500, nil }
+	spec.MonitorConfig.VSDFlags.EncryptionEnabled = false
+	spec.CNIConfig.MTU = intstr.FromInt(1500)
+	_, err = r.GetClusterNetworkMTU(spec)
+	cerr, ok := err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonClusterNetworkMTUInvalid))
+
+	//an explicit mtu is used as is when the uplink cannot be read
+	uplinkMTU = func(string) (int, error) { return 0, fmt.Errorf("no such interface") }
+	mtu, err = r.GetClusterNetworkMTU(spec)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mtu).To(Equal(1500))
+
+	//auto does not guess the uplink mtu
+	spec.CNIConfig.MTU = intstr.FromString(cni.MTUAuto)
+	_, err = r.GetClusterNetworkMTU(spec)
+	cerr, ok = err.(*ClusterNetworkError)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cerr.Reason).To(Equal(ReasonClusterNetworkMTUInvalid))
+	g.Expect(cerr.Message).To(ContainSubstring("cannot read the mtu of uplink eth0"))
+}
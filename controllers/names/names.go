@@ -19,4 +19,6 @@ const (
 	MasterNodeSelector = "nuage.io/monitor-pod"
 	NuageMonitorConfig = "nuage-monitor-config-data"
 	NuageMonitor       = "nuage-monitor"
+	// NuageCleanup is the name of the daemonset cleaning up the nodes on deletion
+	NuageCleanup = "nuage-cleanup"
 )
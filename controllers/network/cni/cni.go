@@ -6,8 +6,13 @@ package cni
 
 import (
 	"fmt"
+	"net/url"
+	"path/filepath"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/placement"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/resources"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -22,8 +27,15 @@ const (
 	CNIVersion = "0.2.0"
 	//LogLevel verbosity level for log messages
 	LogLevel = "info"
-	//MTU setting the interface MTU for packets
-	MTU = 1450
+	//MTUAuto derives the interface MTU from the underlay uplink of the node
+	//the operator runs on
+	MTUAuto = "auto"
+	//MinMTU is the smallest interface MTU allowed
+	MinMTU = 576
+	//VXLANOverhead is the encapsulation overhead of vxlan
+	VXLANOverhead = 50
+	//EncryptionOverhead is the additional overhead when encryption is enabled
+	EncryptionOverhead = 100
 	//NuageSiteID to be used for EVDF personalities
 	NuageSiteID = -1
 	//LogFileSize maximum file size after which rotation happens
@@ -38,11 +50,19 @@ const (
 	StaleEntryTimeout = 600
 	//DefaultResourceName is the name of the resources like sa, role and role binding
 	DefaultResourceName = "nuage-cni"
+	//DefaultKubeConfigPath is where the generated kubeconfig is mounted in
+	//the monitor pods. The cni plugin reads its copy on the host
+	DefaultKubeConfigPath = "/etc/nuage/kubeconfig"
+	//DefaultCPURequest is the cpu requested by the cni pod
+	DefaultCPURequest = "50m"
+	//DefaultMemoryRequest is the memory requested by the cni pod
+	DefaultMemoryRequest = "100Mi"
 )
 
-//Parse validates the CNI config definition and fill in default values
-func Parse(config *operv1.CNIConfigDefinition) error {
-	if err := validate(config); err != nil {
+//Parse validates the CNI config definition and fill in default values. The
+//load balancer url may only be left empty when the monitor service is enabled
+func Parse(config *operv1.CNIConfigDefinition, monitor *operv1.MonitorConfigDefinition) error {
+	if err := validate(config, monitor); err != nil {
 		log.Error(err, "validating vrs config failed")
 		return err
 	}
@@ -51,15 +71,73 @@ func Parse(config *operv1.CNIConfigDefinition) error {
 	return nil
 }
 
-func validate(config *operv1.CNIConfigDefinition) error {
-	if config.MTU > 1450 {
-		return fmt.Errorf("mtu exceeds 1450")
+func validate(config *operv1.CNIConfigDefinition, monitor *operv1.MonitorConfigDefinition) error {
+	if config.MTU.Type == intstr.String && config.MTU.StrVal != MTUAuto {
+		return fmt.Errorf("mtu must be a number or %q", MTUAuto)
+	}
+	if config.MTU.Type == intstr.Int && config.MTU.IntVal != 0 && config.MTU.IntVal < MinMTU {
+		return fmt.Errorf("mtu is less than %d", MinMTU)
 	}
 	if config.NuageSiteID > 0 {
 		return fmt.Errorf("non negative values of site id is not supported")
 	}
-	if len(config.LoadBalancerURL) == 0 {
-		return fmt.Errorf("load balancer url cannot be empty")
+	if len(config.LoadBalancerURL) == 0 && !monitor.Service.Enabled {
+		return fmt.Errorf("load balancer url cannot be empty unless the monitor service is enabled")
+	}
+	if err := validateKubeConfigPath(config.KubeConfigPath); err != nil {
+		return err
+	}
+	if err := validateAPIServerURL(config.APIServerURL); err != nil {
+		return err
+	}
+	if err := placement.Validate(&config.Placement); err != nil {
+		return err
+	}
+	if err := resources.Validate(&config.Resources); err != nil {
+		return err
+	}
+	return nil
+}
+
+// reservedDirs are directories the monitor pods need from the image
+// or the host, the kubeconfig mount would hide them
+var reservedDirs = map[string]bool{
+	"/":          true,
+	"/etc":       true,
+	"/opt":       true,
+	"/usr":       true,
+	"/usr/share": true,
+	"/var":       true,
+	"/var/log":   true,
+	"/var/run":   true,
+}
+
+// validateKubeConfigPath checks that the kubeconfig can be mounted at path.
+// The directory of the path is mounted as a whole
+func validateKubeConfigPath(path string) error {
+	if len(path) == 0 {
+		return nil
+	}
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return fmt.Errorf("kubeconfig path %q must be a clean absolute path", path)
+	}
+	if reservedDirs[filepath.Dir(path)] {
+		return fmt.Errorf("kubeconfig path %q must not be directly under %s", path, filepath.Dir(path))
+	}
+	return nil
+}
+
+// validateAPIServerURL checks the api server url override
+func validateAPIServerURL(s string) error {
+	if len(s) == 0 {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("api server url %q is not valid %v", s, err)
+	}
+	if u.Scheme != "https" || len(u.Host) == 0 {
+		return fmt.Errorf("api server url %q must be of the form https://host[:port]", s)
 	}
 	return nil
 }
@@ -77,8 +155,8 @@ func fillDefaults(config *operv1.CNIConfigDefinition) {
 	if len(config.LogLevel) == 0 {
 		config.LogLevel = LogLevel
 	}
-	if config.MTU == 0 {
-		config.MTU = MTU
+	if config.MTU.Type == intstr.Int && config.MTU.IntVal == 0 {
+		config.MTU = intstr.FromString(MTUAuto)
 	}
 	if config.NuageSiteID == 0 {
 		config.NuageSiteID = -1
@@ -98,6 +176,9 @@ func fillDefaults(config *operv1.CNIConfigDefinition) {
 	if config.StaleEntryTimeout == 0 {
 		config.StaleEntryTimeout = 600
 	}
+	if len(config.KubeConfigPath) == 0 {
+		config.KubeConfigPath = DefaultKubeConfigPath
+	}
 	if len(config.ServiceAccountName) == 0 {
 		config.ServiceAccountName = DefaultResourceName
 	}
@@ -110,4 +191,34 @@ func fillDefaults(config *operv1.CNIConfigDefinition) {
 		config.ClusterRoleBindingName = DefaultResourceName
 	}
 
+	placement.FillDefaults(&config.Placement, placement.DefaultTolerations)
+	resources.FillDefaults(&config.Resources, DefaultCPURequest, DefaultMemoryRequest)
+}
+
+//MaxMTU returns the largest interface MTU that fits the uplink MTU
+//after encapsulation
+func MaxMTU(uplinkMTU int, encryption bool) int {
+	mtu := uplinkMTU - VXLANOverhead
+	if encryption {
+		mtu -= EncryptionOverhead
+	}
+	return mtu
+}
+
+//EffectiveMTU returns the interface MTU to be configured. An explicit
+//MTU is validated against the one derived from the uplink
+func EffectiveMTU(config *operv1.CNIConfigDefinition, uplinkMTU int, encryption bool) (int, error) {
+	max := MaxMTU(uplinkMTU, encryption)
+	if config.MTU.Type == intstr.String {
+		if max < MinMTU {
+			return 0, fmt.Errorf("uplink mtu %d is too small for overlay", uplinkMTU)
+		}
+		return max, nil
+	}
+
+	mtu := config.MTU.IntValue()
+	if mtu > max {
+		return 0, fmt.Errorf("mtu %d exceeds %d supported by uplink mtu %d", mtu, max, uplinkMTU)
+	}
+	return mtu, nil
 }
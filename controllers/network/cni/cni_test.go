@@ -8,7 +8,9 @@ import (
 	"testing"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/placement"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func TestParse(t *testing.T) {
@@ -17,33 +19,110 @@ func TestParse(t *testing.T) {
 	c := &operv1.CNIConfigDefinition{
 		LoadBalancerURL: "https://127.0.0.1:9443",
 	}
+	m := &operv1.MonitorConfigDefinition{}
 
-	err := Parse(c)
+	err := Parse(c, m)
 	g.Expect(err).To(BeNil())
-	g.Expect(c.MTU).To(Equal(1450))
+	g.Expect(c.MTU).To(Equal(intstr.FromString(MTUAuto)))
+	g.Expect(c.Placement.NodeSelector).To(HaveKeyWithValue(placement.OSLabel, placement.DefaultOS))
+	g.Expect(c.Placement.Tolerations).To(Equal(placement.DefaultTolerations))
+	g.Expect(c.Placement.PriorityClassName).To(Equal(placement.DefaultPriorityClassName))
+	g.Expect(c.Resources.Requests.Cpu().String()).To(Equal(DefaultCPURequest))
+	g.Expect(c.Resources.Requests.Memory().String()).To(Equal(DefaultMemoryRequest))
 
 	c.NuageSiteID = -1
-	err = Parse(c)
+	err = Parse(c, m)
 	g.Expect(err).To(BeNil())
 
 	c.NuageSiteID = 10
-	err = Parse(c)
+	err = Parse(c, m)
 	g.Expect(err).Should(HaveOccurred())
 	g.Expect(err.Error()).Should(ContainSubstring("non negative values"))
 
-	c.MTU = 1500
+	c.MTU = intstr.FromString("jumbo")
 	c.NuageSiteID = -1
-	err = Parse(c)
+	err = Parse(c, m)
 	g.Expect(err).Should(HaveOccurred())
-	g.Expect(err.Error()).Should(ContainSubstring("mtu exceeds"))
+	g.Expect(err.Error()).Should(ContainSubstring("mtu must be a number"))
 
-	c.MTU = 1450
-	err = Parse(c)
+	c.MTU = intstr.FromInt(500)
+	err = Parse(c, m)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("mtu is less than"))
+
+	c.MTU = intstr.FromInt(8950)
+	err = Parse(c, m)
 	g.Expect(err).ShouldNot(HaveOccurred())
 	g.Expect(c.ServiceAccountName).To(Equal(DefaultResourceName))
+	g.Expect(c.KubeConfigPath).To(Equal(DefaultKubeConfigPath))
+
+	c.KubeConfigPath = "etc/nuage/kubeconfig"
+	err = Parse(c, m)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("clean absolute path"))
+
+	c.KubeConfigPath = "/usr/share/kubeconfig"
+	err = Parse(c, m)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("must not be directly under /usr/share"))
+
+	c.KubeConfigPath = "/var/lib/nuage/kubeconfig"
+	err = Parse(c, m)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(c.KubeConfigPath).To(Equal("/var/lib/nuage/kubeconfig"))
+
+	c.APIServerURL = "10.0.0.1:6443"
+	err = Parse(c, m)
+	g.Expect(err).Should(HaveOccurred())
+
+	c.APIServerURL = "http://10.0.0.1:6443"
+	err = Parse(c, m)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("must be of the form https://host[:port]"))
+
+	c.APIServerURL = "https://api.example.com:6443"
+	err = Parse(c, m)
+	g.Expect(err).ShouldNot(HaveOccurred())
 
 	c.LoadBalancerURL = ""
-	err = Parse(c)
+	err = Parse(c, m)
 	g.Expect(err).Should(HaveOccurred())
 	g.Expect(err.Error()).Should(ContainSubstring("load balancer url cannot be empty"))
+
+	m.Service.Enabled = true
+	err = Parse(c, m)
+	g.Expect(err).ShouldNot(HaveOccurred())
+}
+
+func TestEffectiveMTU(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := &operv1.CNIConfigDefinition{
+		MTU: intstr.FromString(MTUAuto),
+	}
+
+	mtu, err := EffectiveMTU(c, 1500, false)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mtu).To(Equal(1450))
+
+	mtu, err = EffectiveMTU(c, 9000, true)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mtu).To(Equal(9000 - VXLANOverhead - EncryptionOverhead))
+
+	_, err = EffectiveMTU(c, 576, false)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("too small"))
+
+	c.MTU = intstr.FromInt(8950)
+	mtu, err = EffectiveMTU(c, 9000, false)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(mtu).To(Equal(8950))
+
+	_, err = EffectiveMTU(c, 9000, true)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("mtu 8950 exceeds 8850"))
+
+	c.MTU = intstr.FromInt(1450)
+	_, err = EffectiveMTU(c, 1500, true)
+	g.Expect(err).To(HaveOccurred())
 }
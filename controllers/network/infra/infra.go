@@ -0,0 +1,90 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package infra
+
+import (
+	"fmt"
+	"net"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/placement"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/resources"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	//DefaultPersonality is the default personality of the VRS the infra pod attaches to
+	DefaultPersonality = "vrs"
+	//DefaultCPURequest is the cpu requested by the infra pod
+	DefaultCPURequest = "10m"
+	//DefaultMemoryRequest is the memory requested by the infra pod
+	DefaultMemoryRequest = "50Mi"
+)
+
+var personalities = []string{"vrs", "vrs-g", "avrs", "avrs-g", "vdf", "evdf"}
+
+// defaultTolerations keeps the infra pods off the masters unless they
+// are schedulable
+var defaultTolerations = []corev1.Toleration{
+	{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule, Operator: corev1.TolerationOpExists},
+}
+
+//Parse validates the infra pod config definition and fill in default values.
+//Enterprise, domain and user default to the ones used by the monitor
+func Parse(config *operv1.InfraPodConfigDefenition, vsdMetadata *operv1.Metadata) error {
+	if err := validate(config); err != nil {
+		return fmt.Errorf("validating infra config failed %v", err)
+	}
+
+	fillDefaults(config, vsdMetadata)
+	return nil
+}
+
+func validate(config *operv1.InfraPodConfigDefenition) error {
+	if len(config.VSPPodCIDR) != 0 {
+		if _, _, err := net.ParseCIDR(config.VSPPodCIDR); err != nil {
+			return fmt.Errorf("invalid pod network cidr %s", config.VSPPodCIDR)
+		}
+	}
+
+	if err := placement.Validate(&config.Placement); err != nil {
+		return err
+	}
+	if err := resources.Validate(&config.Resources); err != nil {
+		return err
+	}
+
+	if len(config.VRSPersonality) == 0 {
+		return nil
+	}
+
+	for _, p := range personalities {
+		if config.VRSPersonality == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("personality %s is not supported", config.VRSPersonality)
+}
+
+func fillDefaults(config *operv1.InfraPodConfigDefenition, vsdMetadata *operv1.Metadata) {
+	if len(config.VSPEnterprise) == 0 {
+		config.VSPEnterprise = vsdMetadata.Enterprise
+	}
+
+	if len(config.VSPDomain) == 0 {
+		config.VSPDomain = vsdMetadata.Domain
+	}
+
+	if len(config.VSPUser) == 0 {
+		config.VSPUser = vsdMetadata.User
+	}
+
+	if len(config.VRSPersonality) == 0 {
+		config.VRSPersonality = DefaultPersonality
+	}
+
+	placement.FillDefaults(&config.Placement, defaultTolerations)
+	resources.FillDefaults(&config.Resources, DefaultCPURequest, DefaultMemoryRequest)
+}
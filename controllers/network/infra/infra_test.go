@@ -0,0 +1,58 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package infra
+
+import (
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	. "github.com/onsi/gomega"
+)
+
+var m = &operv1.Metadata{
+	Enterprise: "monitor-enterprise",
+	Domain:     "monitor-domain",
+	User:       "monitor-user",
+}
+
+func TestParse(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := &operv1.InfraPodConfigDefenition{}
+	err := Parse(c, m)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.VSPEnterprise).To(Equal("monitor-enterprise"))
+	g.Expect(c.VSPDomain).To(Equal("monitor-domain"))
+	g.Expect(c.VSPUser).To(Equal("monitor-user"))
+	g.Expect(c.VSPPodCIDR).To(BeEmpty())
+	g.Expect(c.VRSPersonality).To(Equal(DefaultPersonality))
+	g.Expect(c.Placement.Tolerations).To(Equal(defaultTolerations))
+	g.Expect(c.Placement.PriorityClassName).To(Equal("system-node-critical"))
+	g.Expect(c.Resources.Requests.Memory().String()).To(Equal(DefaultMemoryRequest))
+
+	c = &operv1.InfraPodConfigDefenition{
+		VSPEnterprise:  "infra",
+		VSPDomain:      "infra-domain",
+		VSPUser:        "infra-user",
+		VSPPodCIDR:     "70.70.0.0/16",
+		VRSPersonality: "avrs",
+	}
+	err = Parse(c, m)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.VSPEnterprise).To(Equal("infra"))
+	g.Expect(c.VRSPersonality).To(Equal("avrs"))
+
+	c.VSPPodCIDR = "70.70.0/16"
+	err = Parse(c, m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("validating infra config failed"))
+	g.Expect(err.Error()).To(ContainSubstring("invalid pod network cidr"))
+
+	c.VSPPodCIDR = ""
+	c.VRSPersonality = "ovs"
+	err = Parse(c, m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("personality ovs is not supported"))
+}
@@ -5,10 +5,22 @@
 package monitor
 
 import (
+	"encoding/pem"
 	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
 	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/placement"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 var (
@@ -18,8 +30,41 @@ var (
 	DefaultRestServerAddress = "0.0.0.0"
 	//DefaultRestServerPort is the default rest server port
 	DefaultRestServerPort = 9443
+	//DefaultCPURequest is the cpu requested by the monitor
+	DefaultCPURequest = "100m"
+	//DefaultMemoryRequest is the memory requested by the monitor
+	DefaultMemoryRequest = "200Mi"
 )
 
+const (
+	//MasterRoleLabel is the role label of the control plane nodes up to kubernetes 1.19
+	MasterRoleLabel = "node-role.kubernetes.io/master"
+	//ControlPlaneRoleLabel is the role label of the control plane nodes since kubernetes 1.20
+	ControlPlaneRoleLabel = "node-role.kubernetes.io/control-plane"
+
+	//DefaultVSPVersion is the VSD api version queried by the monitor
+	DefaultVSPVersion = "v6"
+	//MaxLogLevel is the least verbose log level, 0 => INFO, 1 => WARNING, 2 => ERROR, 3 => FATAL
+	MaxLogLevel = 3
+	//DefaultLogDir is where the monitor writes its logs
+	DefaultLogDir = "/var/log/nuagekubemon/"
+	//DefaultEtcdEndpoint is the local etcd member of a kubeadm master
+	DefaultEtcdEndpoint = "https://127.0.0.1:2379"
+	//DefaultEtcdCA is the kubeadm etcd ca on the masters
+	DefaultEtcdCA = "/etc/kubernetes/pki/etcd/ca.crt"
+	//DefaultEtcdCertFile is the kubeadm etcd peer certificate on the masters
+	DefaultEtcdCertFile = "/etc/kubernetes/pki/etcd/peer.crt"
+	//DefaultEtcdKeyFile is the kubeadm etcd peer key on the masters
+	DefaultEtcdKeyFile = "/etc/kubernetes/pki/etcd/peer.key"
+	//EtcdCertDir is where the etcd client cert secret is mounted
+	EtcdCertDir = "/etc/nuage-etcd"
+	//KubernetesPKIDir is the only master directory mounted in the monitor,
+	//the etcd certificate paths must be under it
+	KubernetesPKIDir = "/etc/kubernetes/pki"
+)
+
+var vspVersionRegex = regexp.MustCompile(`^v[0-9]+(_[0-9]+)?$`)
+
 //Parse validates the Monitor config definition and fill in default values
 func Parse(config *operv1.MonitorConfigDefinition) error {
 	if err := validate(config); err != nil {
@@ -30,25 +75,92 @@ func Parse(config *operv1.MonitorConfigDefinition) error {
 	return nil
 }
 
-func validate(config *operv1.MonitorConfigDefinition) error {
-	if len(config.VSDAddress) == 0 {
-		return fmt.Errorf("invalid vsd ip address")
+//ValidateOpenShift checks the parts of the config that differ on
+//openshift. The kubernetes pki dir of the masters is not mounted there, so
+//the etcd client certificate has to come from a secret
+func ValidateOpenShift(config *operv1.MonitorConfigDefinition) error {
+	if config.Etcd.ClientCertSecret == nil {
+		return fmt.Errorf("etcd.clientCertSecret is required on openshift, the etcd certificates of the masters are not mounted")
 	}
+	return nil
+}
+
+func validate(config *operv1.MonitorConfigDefinition) error {
+	if len(config.VSDEndpoints) != 0 {
+		if err := validateVSDEndpoints(config); err != nil {
+			return fmt.Errorf("vsd endpoints validation failed: %v", err)
+		}
+	} else {
+		if len(config.VSDAddress) == 0 {
+			return fmt.Errorf("invalid vsd ip address")
+		}
 
-	if config.VSDPort <= 0 {
-		return fmt.Errorf("invalid vsd port address")
+		if config.VSDPort <= 0 {
+			return fmt.Errorf("invalid vsd port address")
+		}
 	}
 
 	if err := validateMetadata(config.VSDMetadata); err != nil {
 		return fmt.Errorf("vsd metadata validation failed: %v", err)
 	}
 
+	if err := validateVSDCA(&config.VSDCA); err != nil {
+		return fmt.Errorf("vsd ca validation failed: %v", err)
+	}
+
 	if config.RestServerPort < 0 {
 		return fmt.Errorf("invalid rest server port")
 	}
+	if _, err := ControlPlaneSelectors(config); err != nil {
+		return err
+	}
+	if len(config.VSPVersion) != 0 && !vspVersionRegex.MatchString(config.VSPVersion) {
+		return fmt.Errorf("invalid vsp version %q, expected a version like v6 or v5_0", config.VSPVersion)
+	}
+	if config.LogLevel < 0 || config.LogLevel > MaxLogLevel {
+		return fmt.Errorf("log level must be between 0 and %d", MaxLogLevel)
+	}
+	if len(config.LogDir) != 0 && !filepath.IsAbs(config.LogDir) {
+		return fmt.Errorf("log dir %q must be an absolute path", config.LogDir)
+	}
+	if err := validateEtcd(&config.Etcd); err != nil {
+		return fmt.Errorf("etcd config validation failed: %v", err)
+	}
+	if err := placement.Validate(&config.Placement); err != nil {
+		return err
+	}
+	return resources.Validate(&config.Resources)
+}
+
+func validateVSDEndpoints(config *operv1.MonitorConfigDefinition) error {
+	seen := map[operv1.VSDEndpoint]bool{}
+	for _, e := range config.VSDEndpoints {
+		if net.ParseIP(e.Address) == nil {
+			if errs := validation.IsDNS1123Subdomain(e.Address); len(errs) != 0 {
+				return fmt.Errorf("invalid address %q: %s", e.Address, strings.Join(errs, ", "))
+			}
+		}
+		if e.Port <= 0 || e.Port > 65535 {
+			return fmt.Errorf("invalid port %d of %s", e.Port, e.Address)
+		}
+		if seen[e] {
+			return fmt.Errorf("%s:%d is listed twice", e.Address, e.Port)
+		}
+		seen[e] = true
+	}
 	return nil
 }
 
+//VSDEndpoints returns the VSDs of the cluster in order of preference. Without
+//vsdEndpoints it is the single VSD of vsdAddress and vsdPort, they are
+//ignored otherwise
+func VSDEndpoints(config *operv1.MonitorConfigDefinition) []operv1.VSDEndpoint {
+	if len(config.VSDEndpoints) != 0 {
+		return config.VSDEndpoints
+	}
+	return []operv1.VSDEndpoint{{Address: config.VSDAddress, Port: config.VSDPort}}
+}
+
 func validateMetadata(m operv1.Metadata) error {
 	if len(m.Enterprise) == 0 {
 		return fmt.Errorf("enterprise name cannot be empty")
@@ -68,6 +180,81 @@ func validateMetadata(m operv1.Metadata) error {
 	return nil
 }
 
+func validateVSDCA(ca *operv1.VSDCADefinition) error {
+	sources := 0
+	if len(ca.CA) != 0 {
+		sources++
+		if block, _ := pem.Decode([]byte(ca.CA)); block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("ca is not a pem encoded certificate")
+		}
+	}
+	if ca.SecretRef != nil {
+		sources++
+		if len(ca.SecretRef.Name) == 0 {
+			return fmt.Errorf("secret name cannot be empty")
+		}
+	}
+	if ca.ConfigMapRef != nil {
+		sources++
+		if len(ca.ConfigMapRef.Name) == 0 {
+			return fmt.Errorf("config map name cannot be empty")
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("only one of ca, secretRef and configMapRef can be set")
+	}
+	return nil
+}
+
+func validateEtcd(e *operv1.EtcdConfigDefinition) error {
+	for _, endpoint := range e.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid endpoint %q", endpoint)
+		}
+	}
+
+	//the certificate paths are replaced by the ones of the secret. The
+	//defaults of either are accepted, they are filled into stored configs
+	if e.ClientCertSecret != nil {
+		if len(e.ClientCertSecret.Name) == 0 {
+			return fmt.Errorf("client cert secret name cannot be empty")
+		}
+		ca, cert, key := etcdSecretPaths()
+		for _, p := range [][3]string{
+			{e.CA, DefaultEtcdCA, ca},
+			{e.CertFile, DefaultEtcdCertFile, cert},
+			{e.KeyFile, DefaultEtcdKeyFile, key},
+		} {
+			if len(p[0]) != 0 && p[0] != p[1] && p[0] != p[2] {
+				return fmt.Errorf("certificate path %q cannot be set together with the client cert secret", p[0])
+			}
+		}
+		return nil
+	}
+
+	for _, path := range []string{e.CA, e.CertFile, e.KeyFile} {
+		if len(path) == 0 {
+			continue
+		}
+		if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+			return fmt.Errorf("certificate path %q must be a clean absolute path", path)
+		}
+		if !strings.HasPrefix(path, KubernetesPKIDir+"/") {
+			return fmt.Errorf("certificate path %q must be under %s, use the client cert secret for other locations", path, KubernetesPKIDir)
+		}
+	}
+	return nil
+}
+
+// etcdSecretPaths returns where the ca, certificate and key of the etcd
+// client cert secret are mounted
+func etcdSecretPaths() (string, string, string) {
+	return filepath.Join(EtcdCertDir, corev1.ServiceAccountRootCAKey),
+		filepath.Join(EtcdCertDir, corev1.TLSCertKey),
+		filepath.Join(EtcdCertDir, corev1.TLSPrivateKeyKey)
+}
+
 func fillDefaults(config *operv1.MonitorConfigDefinition) {
 	//config.VSDFlags are all boolean. They default to false
 	//which we want
@@ -94,4 +281,85 @@ func fillDefaults(config *operv1.MonitorConfigDefinition) {
 	if len(config.MasterNodeSelector) == 0 {
 		config.MasterNodeSelector = names.MasterNodeSelector
 	}
+
+	if len(config.VSPVersion) == 0 {
+		config.VSPVersion = DefaultVSPVersion
+	}
+
+	if len(config.LogDir) == 0 {
+		config.LogDir = DefaultLogDir
+	}
+
+	fillEtcdDefaults(&config.Etcd)
+
+	//the monitor runs on the masters, so it also tolerates their taint
+	tolerations := append([]corev1.Toleration{}, placement.DefaultTolerations...)
+	for _, role := range []string{MasterRoleLabel, ControlPlaneRoleLabel} {
+		tolerations = append(tolerations, corev1.Toleration{
+			Key:      role,
+			Effect:   corev1.TaintEffectNoSchedule,
+			Operator: corev1.TolerationOpExists,
+		})
+	}
+	placement.FillDefaults(&config.Placement, tolerations)
+
+	resources.FillDefaults(&config.Resources, DefaultCPURequest, DefaultMemoryRequest)
+}
+
+//RenderConfig returns the monitor config the manifests are rendered with.
+//The monitor is always restricted to the nodes carrying the master selector.
+//It is only added to the rendered node selector, the parsed spec is saved
+//back to the cluster and would keep a previous master selector
+func RenderConfig(config operv1.MonitorConfigDefinition) operv1.MonitorConfigDefinition {
+	nodeSelector := map[string]string{}
+	for k, v := range config.Placement.NodeSelector {
+		nodeSelector[k] = v
+	}
+	nodeSelector[config.MasterNodeSelector] = ""
+	config.Placement.NodeSelector = nodeSelector
+	return config
+}
+
+//ControlPlaneSelectors returns the selectors of the nodes the monitor runs
+//on, a node matching any of them is a master. Without a configured selector
+//the nodes with either control plane role label are masters
+func ControlPlaneSelectors(config *operv1.MonitorConfigDefinition) ([]labels.Selector, error) {
+	if len(config.ControlPlaneSelector) != 0 {
+		selector, err := labels.Parse(config.ControlPlaneSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid control plane selector %q: %v", config.ControlPlaneSelector, err)
+		}
+		return []labels.Selector{selector}, nil
+	}
+
+	selectors := []labels.Selector{}
+	for _, role := range []string{MasterRoleLabel, ControlPlaneRoleLabel} {
+		req, err := labels.NewRequirement(role, selection.Exists, nil)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, labels.NewSelector().Add(*req))
+	}
+	return selectors, nil
+}
+
+func fillEtcdDefaults(e *operv1.EtcdConfigDefinition) {
+	if len(e.Endpoints) == 0 {
+		e.Endpoints = []string{DefaultEtcdEndpoint}
+	}
+
+	if e.ClientCertSecret != nil {
+		e.CA, e.CertFile, e.KeyFile = etcdSecretPaths()
+		return
+	}
+
+	if len(e.CA) == 0 {
+		e.CA = DefaultEtcdCA
+	}
+	if len(e.CertFile) == 0 {
+		e.CertFile = DefaultEtcdCertFile
+	}
+	if len(e.KeyFile) == 0 {
+		e.KeyFile = DefaultEtcdKeyFile
+	}
 }
@@ -5,10 +5,15 @@
 package monitor
 
 import (
+	"encoding/json"
 	"testing"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/placement"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 var c = &operv1.MonitorConfigDefinition{
@@ -40,6 +45,16 @@ func TestParse(t *testing.T) {
 	g.Expect(c.RestServerAddress).To(Equal(DefaultRestServerAddress))
 	g.Expect(c.RestServerPort).To(Equal(DefaultRestServerPort))
 	g.Expect(c.ServiceAccountName).To(Equal(DefaultResourceName))
+	g.Expect(c.Placement.NodeSelector).To(Equal(map[string]string{
+		placement.OSLabel: placement.DefaultOS,
+	}))
+	g.Expect(RenderConfig(*c).Placement.NodeSelector).To(Equal(map[string]string{
+		placement.OSLabel:        placement.DefaultOS,
+		names.MasterNodeSelector: "",
+	}))
+	g.Expect(c.Placement.Tolerations).To(HaveLen(len(placement.DefaultTolerations) + 2))
+	g.Expect(c.Placement.PriorityClassName).To(Equal(placement.DefaultPriorityClassName))
+	g.Expect(c.Resources.Requests.Cpu().String()).To(Equal(DefaultCPURequest))
 
 	c.RestServerAddress = "acv"
 	c.RestServerPort = 1000
@@ -79,4 +94,222 @@ func TestParse(t *testing.T) {
 	err = Parse(c)
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(c.ServiceAccountName).To(Equal("test-name"))
+
+	c.Placement.NodeSelector = map[string]string{"pool": "masters"}
+	err = Parse(c)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(RenderConfig(*c).Placement.NodeSelector).To(Equal(map[string]string{
+		"pool":                   "masters",
+		names.MasterNodeSelector: "",
+	}))
+	g.Expect(c.Placement.NodeSelector).To(Equal(map[string]string{"pool": "masters"}))
+
+	//a changed master selector replaces the previous one on a defaulted spec
+	c.MasterNodeSelector = "example.com/nuage-monitor"
+	err = Parse(c)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(RenderConfig(*c).Placement.NodeSelector).To(Equal(map[string]string{
+		"pool":                      "masters",
+		"example.com/nuage-monitor": "",
+	}))
+	c.MasterNodeSelector = names.MasterNodeSelector
+
+	c.Placement.PriorityClassName = "Not_Valid"
+	err = Parse(c)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid priority class name"))
+	c.Placement.PriorityClassName = ""
+}
+
+func TestParseMonitorSettings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	m := c.DeepCopy()
+	err := Parse(m)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(m.VSPVersion).To(Equal(DefaultVSPVersion))
+	g.Expect(m.LogLevel).To(Equal(0))
+	g.Expect(m.LogDir).To(Equal(DefaultLogDir))
+	g.Expect(m.Etcd.Endpoints).To(Equal([]string{DefaultEtcdEndpoint}))
+	g.Expect(m.Etcd.CA).To(Equal(DefaultEtcdCA))
+	g.Expect(m.Etcd.CertFile).To(Equal(DefaultEtcdCertFile))
+	g.Expect(m.Etcd.KeyFile).To(Equal(DefaultEtcdKeyFile))
+
+	m.VSPVersion = "6.0"
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid vsp version"))
+
+	m.VSPVersion = "v5_0"
+	m.LogLevel = 4
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("log level must be between 0 and 3"))
+
+	m.LogLevel = 1
+	m.LogDir = "var/log"
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("must be an absolute path"))
+
+	m.LogDir = "/var/log/monitor/"
+	m.Etcd.Endpoints = []string{"10.0.0.1:2379"}
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("etcd config validation failed"))
+
+	m.Etcd.Endpoints = []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"}
+	m.Etcd.CertFile = "client.crt"
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("must be a clean absolute path"))
+
+	//only the kubernetes pki dir of the masters is mounted
+	m.Etcd.CertFile = "/etc/etcd/client.crt"
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("must be under /etc/kubernetes/pki"))
+
+	m.Etcd.CertFile = "/etc/kubernetes/pki/../etcd/client.crt"
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("must be a clean absolute path"))
+
+	m.Etcd.CertFile = "/etc/kubernetes/pki/etcd/client.crt"
+	m.Etcd.ClientCertSecret = &corev1.LocalObjectReference{Name: "etcd-client"}
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("cannot be set together with the client cert secret"))
+
+	//the filled in defaults do not conflict with the secret
+	m.Etcd.CertFile = DefaultEtcdCertFile
+	err = Parse(m)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(m.VSPVersion).To(Equal("v5_0"))
+	g.Expect(m.LogLevel).To(Equal(1))
+	g.Expect(m.LogDir).To(Equal("/var/log/monitor/"))
+	g.Expect(m.Etcd.Endpoints).To(HaveLen(2))
+	g.Expect(m.Etcd.CA).To(Equal(EtcdCertDir + "/ca.crt"))
+	g.Expect(m.Etcd.CertFile).To(Equal(EtcdCertDir + "/tls.crt"))
+	g.Expect(m.Etcd.KeyFile).To(Equal(EtcdCertDir + "/tls.key"))
+
+	g.Expect(Parse(m)).To(Succeed())
+
+	m.Etcd.ClientCertSecret.Name = ""
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("client cert secret name cannot be empty"))
+}
+
+func TestValidateOpenShift(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	m := c.DeepCopy()
+	err := ValidateOpenShift(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("etcd.clientCertSecret is required on openshift"))
+
+	m.Etcd.ClientCertSecret = &corev1.LocalObjectReference{Name: "etcd-client"}
+	g.Expect(ValidateOpenShift(m)).To(Succeed())
+}
+
+func TestParseVSDEndpoints(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	m := c.DeepCopy()
+	g.Expect(Parse(m)).To(Succeed())
+	g.Expect(VSDEndpoints(m)).To(Equal([]operv1.VSDEndpoint{{Address: m.VSDAddress, Port: m.VSDPort}}))
+
+	m.VSDAddress = ""
+	m.VSDPort = 0
+	m.VSDEndpoints = []operv1.VSDEndpoint{
+		{Address: "vsd1.example.com", Port: 7443},
+		{Address: "10.0.0.2", Port: 7443},
+		{Address: "fd00::3", Port: 7443},
+	}
+	g.Expect(Parse(m)).To(Succeed())
+	g.Expect(m.VSDAddress).To(BeEmpty())
+	g.Expect(m.VSDPort).To(BeZero())
+	g.Expect(VSDEndpoints(m)).To(HaveLen(3))
+
+	//the saved spec parses again after the endpoints are reordered
+	data, err := json.Marshal(m)
+	g.Expect(err).NotTo(HaveOccurred())
+	saved := &operv1.MonitorConfigDefinition{}
+	g.Expect(json.Unmarshal(data, saved)).To(Succeed())
+	saved.VSDEndpoints[0], saved.VSDEndpoints[1] = saved.VSDEndpoints[1], saved.VSDEndpoints[0]
+	g.Expect(Parse(saved)).To(Succeed())
+	g.Expect(VSDEndpoints(saved)[0].Address).To(Equal("10.0.0.2"))
+
+	//vsdAddress and vsdPort are ignored along with the endpoints
+	m.VSDAddress = "10.0.0.9"
+	m.VSDPort = 8443
+	g.Expect(Parse(m)).To(Succeed())
+	g.Expect(VSDEndpoints(m)[0].Address).To(Equal("vsd1.example.com"))
+
+	m.VSDEndpoints[1].Address = "VSD_2"
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid address \"VSD_2\""))
+
+	m.VSDEndpoints[1].Address = "vsd1.example.com"
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("vsd1.example.com:7443 is listed twice"))
+
+	m.VSDEndpoints[1].Port = 65536
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid port 65536"))
+}
+
+func TestParseVSDCA(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	m := c.DeepCopy()
+	m.VSDCA.CA = "not a certificate"
+	err := Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("ca is not a pem encoded certificate"))
+
+	m.VSDCA.CA = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	g.Expect(Parse(m)).To(Succeed())
+
+	m.VSDCA.SecretRef = &corev1.LocalObjectReference{Name: "vsd-ca"}
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("only one of ca, secretRef and configMapRef can be set"))
+
+	m.VSDCA.CA = ""
+	g.Expect(Parse(m)).To(Succeed())
+
+	m.VSDCA.SecretRef = nil
+	m.VSDCA.ConfigMapRef = &corev1.LocalObjectReference{}
+	err = Parse(m)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("config map name cannot be empty"))
+}
+
+func TestControlPlaneSelectors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := &operv1.MonitorConfigDefinition{}
+	selectors, err := ControlPlaneSelectors(config)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(selectors).To(HaveLen(2))
+	g.Expect(selectors[0].Matches(labels.Set{MasterRoleLabel: ""})).To(BeTrue())
+	g.Expect(selectors[1].Matches(labels.Set{ControlPlaneRoleLabel: ""})).To(BeTrue())
+	g.Expect(selectors[0].Matches(labels.Set{"node-role.kubernetes.io/worker": ""})).To(BeFalse())
+
+	config.ControlPlaneSelector = "nuage.io/master=true"
+	selectors, err = ControlPlaneSelectors(config)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(selectors).To(HaveLen(1))
+	g.Expect(selectors[0].Matches(labels.Set{"nuage.io/master": "true"})).To(BeTrue())
+	g.Expect(selectors[0].Matches(labels.Set{MasterRoleLabel: ""})).To(BeFalse())
+
+	config.ControlPlaneSelector = "a in (b"
+	_, err = ControlPlaneSelectors(config)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid control plane selector"))
 }
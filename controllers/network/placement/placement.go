@@ -0,0 +1,66 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package placement
+
+import (
+	"fmt"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	//DefaultPriorityClassName keeps the nuage pods from being preempted
+	DefaultPriorityClassName = "system-node-critical"
+	//OSLabel is the node label holding the operating system
+	OSLabel = "kubernetes.io/os"
+	//DefaultOS is the operating system the nuage pods run on
+	DefaultOS = "linux"
+)
+
+//DefaultTolerations lets the pods run on nodes with any NoSchedule or
+//NoExecute taint
+var DefaultTolerations = []corev1.Toleration{
+	{Effect: corev1.TaintEffectNoSchedule, Operator: corev1.TolerationOpExists},
+	{Key: "CriticalAddonsOnly", Operator: corev1.TolerationOpExists},
+	{Effect: corev1.TaintEffectNoExecute, Operator: corev1.TolerationOpExists},
+}
+
+//Validate checks the node selector and priority class name of the placement
+func Validate(p *operv1.PlacementDefinition) error {
+	for k, v := range p.NodeSelector {
+		if errs := validation.IsQualifiedName(k); len(errs) != 0 {
+			return fmt.Errorf("invalid node selector key %s: %v", k, errs)
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) != 0 {
+			return fmt.Errorf("invalid node selector value %s: %v", v, errs)
+		}
+	}
+
+	if len(p.PriorityClassName) != 0 {
+		if errs := validation.IsDNS1123Subdomain(p.PriorityClassName); len(errs) != 0 {
+			return fmt.Errorf("invalid priority class name %s: %v", p.PriorityClassName, errs)
+		}
+	}
+	return nil
+}
+
+//FillDefaults fills in the settings not given by the user. The pods are
+//scheduled on linux nodes with the given tolerations and the node critical
+//priority class
+func FillDefaults(p *operv1.PlacementDefinition, tolerations []corev1.Toleration) {
+	if p.NodeSelector == nil {
+		p.NodeSelector = map[string]string{OSLabel: DefaultOS}
+	}
+
+	if p.Tolerations == nil {
+		p.Tolerations = append([]corev1.Toleration{}, tolerations...)
+	}
+
+	if len(p.PriorityClassName) == 0 {
+		p.PriorityClassName = DefaultPriorityClassName
+	}
+}
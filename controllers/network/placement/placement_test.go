@@ -0,0 +1,65 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package placement
+
+import (
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := &operv1.PlacementDefinition{
+		NodeSelector:      map[string]string{"node-role.kubernetes.io/worker": "", OSLabel: DefaultOS},
+		PriorityClassName: "nuage-critical",
+	}
+	g.Expect(Validate(p)).To(Succeed())
+
+	p.NodeSelector = map[string]string{"bad key!": ""}
+	err := Validate(p)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid node selector key bad key!"))
+
+	p.NodeSelector = map[string]string{"pool": "gpu nodes"}
+	err = Validate(p)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid node selector value gpu nodes"))
+
+	p.NodeSelector = nil
+	p.PriorityClassName = "Critical_Class"
+	err = Validate(p)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid priority class name"))
+}
+
+func TestFillDefaults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := &operv1.PlacementDefinition{}
+	FillDefaults(p, DefaultTolerations)
+	g.Expect(p.NodeSelector).To(Equal(map[string]string{OSLabel: DefaultOS}))
+	g.Expect(p.Tolerations).To(Equal(DefaultTolerations))
+	g.Expect(p.PriorityClassName).To(Equal(DefaultPriorityClassName))
+	g.Expect(p.Affinity).To(BeNil())
+
+	gpu := []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}}
+	p = &operv1.PlacementDefinition{
+		NodeSelector:      map[string]string{"pool": "gpu"},
+		Tolerations:       gpu,
+		PriorityClassName: "nuage-critical",
+	}
+	FillDefaults(p, DefaultTolerations)
+	g.Expect(p.NodeSelector).To(Equal(map[string]string{"pool": "gpu"}))
+	g.Expect(p.Tolerations).To(Equal(gpu))
+	g.Expect(p.PriorityClassName).To(Equal("nuage-critical"))
+
+	p = &operv1.PlacementDefinition{Tolerations: []corev1.Toleration{}}
+	FillDefaults(p, DefaultTolerations)
+	g.Expect(p.Tolerations).To(BeEmpty())
+}
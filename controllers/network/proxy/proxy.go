@@ -0,0 +1,82 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+)
+
+//DefaultNoProxy are always reached directly, they are local or in cluster
+var DefaultNoProxy = []string{"localhost", "127.0.0.1", ".svc", ".cluster.local"}
+
+//Parse validates the proxy config definition
+func Parse(config *operv1.ProxyConfigDefinition) error {
+	if err := validate(config); err != nil {
+		return fmt.Errorf("validating proxy config failed %v", err)
+	}
+	return nil
+}
+
+func validate(config *operv1.ProxyConfigDefinition) error {
+	for _, p := range []string{config.HTTPProxy, config.HTTPSProxy} {
+		if len(p) == 0 {
+			continue
+		}
+		u, err := url.Parse(p)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid proxy url %q, expected http[s]://host[:port]", p)
+		}
+	}
+	for _, entry := range strings.Split(config.NoProxy, ",") {
+		if err := validateNoProxyEntry(strings.TrimSpace(entry)); err != nil {
+			return err
+		}
+	}
+	if config.TrustedCA != nil && len(config.TrustedCA.Name) == 0 {
+		return fmt.Errorf("trusted ca config map name cannot be empty")
+	}
+	return nil
+}
+
+// validateNoProxyEntry accepts hosts, domains, ips and networks
+func validateNoProxyEntry(entry string) error {
+	if strings.ContainsAny(entry, " \t") {
+		return fmt.Errorf("invalid no proxy entry %q", entry)
+	}
+	if strings.Contains(entry, "/") {
+		if _, _, err := net.ParseCIDR(entry); err != nil {
+			return fmt.Errorf("invalid no proxy network %q", entry)
+		}
+	}
+	return nil
+}
+
+//NoProxy joins the configured no proxy list with the entries that must not
+//go through the proxy. Duplicates and empty entries are dropped
+func NoProxy(configured string, entries ...string) string {
+	seen := map[string]bool{}
+	noProxy := []string{}
+	add := func(e string) {
+		e = strings.TrimSpace(e)
+		if len(e) == 0 || seen[e] {
+			return
+		}
+		seen[e] = true
+		noProxy = append(noProxy, e)
+	}
+
+	for _, e := range strings.Split(configured, ",") {
+		add(e)
+	}
+	for _, e := range append(append([]string{}, DefaultNoProxy...), entries...) {
+		add(e)
+	}
+	return strings.Join(noProxy, ",")
+}
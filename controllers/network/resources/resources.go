@@ -0,0 +1,46 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+//Validate checks that no request exceeds its limit
+func Validate(r *corev1.ResourceRequirements) error {
+	for name, request := range r.Requests {
+		limit, ok := r.Limits[name]
+		if ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s request %s is more than the limit %s", name, request.String(), limit.String())
+		}
+	}
+	return nil
+}
+
+//FillDefaults requests the given cpu and memory unless the user already
+//set a request or a limit for them. A limit without a request makes the
+//request default to the limit
+func FillDefaults(r *corev1.ResourceRequirements, cpu, memory string) {
+	defaults := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+
+	for name, quantity := range defaults {
+		if _, ok := r.Requests[name]; ok {
+			continue
+		}
+		if _, ok := r.Limits[name]; ok {
+			continue
+		}
+		if r.Requests == nil {
+			r.Requests = corev1.ResourceList{}
+		}
+		r.Requests[name] = quantity
+	}
+}
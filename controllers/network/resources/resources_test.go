@@ -0,0 +1,47 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestFillDefaults(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &corev1.ResourceRequirements{}
+	FillDefaults(r, "100m", "200Mi")
+	g.Expect(r.Requests.Cpu().String()).To(Equal("100m"))
+	g.Expect(r.Requests.Memory().String()).To(Equal("200Mi"))
+	g.Expect(r.Limits).To(BeNil())
+
+	r = &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("50Mi")},
+	}
+	FillDefaults(r, "100m", "200Mi")
+	g.Expect(r.Requests.Cpu().String()).To(Equal("1"))
+	_, ok := r.Requests[corev1.ResourceMemory]
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	g.Expect(Validate(r)).To(Succeed())
+
+	r.Limits[corev1.ResourceMemory] = resource.MustParse("50Mi")
+	err := Validate(r)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("memory request 100Mi is more than the limit 50Mi"))
+}
@@ -9,11 +9,22 @@ import (
 	"net"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/placement"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/resources"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
 	//VRSPlatform defines the VRS platform
 	VRSPlatform = "kvm, k8s"
+	//MaxControllersPerRole is the number of active or standby controllers
+	//the VRS image takes, NUAGE_ACTIVE_CONTROLLER and NUAGE_STANDBY_CONTROLLER
+	//hold a single address each
+	MaxControllersPerRole = 1
+	//DefaultCPURequest is the cpu requested by the vrs
+	DefaultCPURequest = "200m"
+	//DefaultMemoryRequest is the memory requested by the vrs
+	DefaultMemoryRequest = "500Mi"
 )
 
 //Parse validates the VRS config definition and fill in default values
@@ -26,25 +37,89 @@ func Parse(config *operv1.VRSConfigDefinition) error {
 	return nil
 }
 
+//Controllers returns the active and standby controllers. The first of the
+//legacy controllers list is active, the others are standby
+func Controllers(config *operv1.VRSConfigDefinition) ([]string, []string) {
+	if len(config.Controllers) != 0 {
+		return config.Controllers[:1], config.Controllers[1:]
+	}
+	return config.ActiveControllers, config.StandbyControllers
+}
+
+//RenderConfig returns the vrs config the manifests are rendered with. The
+//roles of the legacy controllers are only rendered, the parsed spec is saved
+//back to the cluster and has to validate again
+func RenderConfig(config operv1.VRSConfigDefinition) operv1.VRSConfigDefinition {
+	config.ActiveControllers, config.StandbyControllers = Controllers(&config)
+	config.Controllers = nil
+	return config
+}
+
 func validate(config *operv1.VRSConfigDefinition) error {
-	if len(config.Controllers) == 0 {
+	if len(config.Controllers) != 0 && (len(config.ActiveControllers) != 0 || len(config.StandbyControllers) != 0) {
+		return fmt.Errorf("controllers cannot be used along with active and standby controllers")
+	}
+	active, standby := Controllers(config)
+
+	if len(active) == 0 {
 		return fmt.Errorf("atleast one controller is expected")
 	}
-	for _, controllerIP := range config.Controllers {
-		ip := net.ParseIP(controllerIP)
-		if ip == nil {
-			return fmt.Errorf("controller ip is not valid")
+	if len(active) > MaxControllersPerRole {
+		return fmt.Errorf("%d active controllers given, vrs supports %d", len(active), MaxControllersPerRole)
+	}
+	if len(standby) > MaxControllersPerRole {
+		return fmt.Errorf("%d standby controllers given, vrs supports %d", len(standby), MaxControllersPerRole)
+	}
+
+	seen := map[string]bool{}
+	for _, controller := range append(append([]string{}, active...), standby...) {
+		if err := validateController(controller); err != nil {
+			return err
+		}
+		if seen[controller] {
+			return fmt.Errorf("controller %s is given more than once", controller)
 		}
+		seen[controller] = true
 	}
 
 	if len(config.UnderlayUplink) == 0 {
 		return fmt.Errorf("underlay uplink cannot be empty")
 	}
+	if err := placement.Validate(&config.Placement); err != nil {
+		return err
+	}
+	return resources.Validate(&config.Resources)
+}
+
+// validateController accepts an ip address or a dns name
+func validateController(controller string) error {
+	if ip := net.ParseIP(controller); ip != nil {
+		return nil
+	}
+	if !isHostname(controller) {
+		return fmt.Errorf("controller ip is not valid")
+	}
+	if errs := validation.IsDNS1123Subdomain(controller); len(errs) != 0 {
+		return fmt.Errorf("controller hostname %s is not valid: %v", controller, errs)
+	}
 	return nil
 }
 
+// isHostname returns false for strings that only look like a malformed ip
+func isHostname(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && c != '.' && c != ':' {
+			return true
+		}
+	}
+	return false
+}
+
 func fillDefaults(config *operv1.VRSConfigDefinition) {
 	if len(config.Platform) == 0 {
 		config.Platform = VRSPlatform
 	}
+
+	placement.FillDefaults(&config.Placement, placement.DefaultTolerations)
+	resources.FillDefaults(&config.Resources, DefaultCPURequest, DefaultMemoryRequest)
 }
@@ -5,6 +5,7 @@
 package vrs
 
 import (
+	"encoding/json"
 	"testing"
 
 	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
@@ -26,6 +27,8 @@ func TestParse(t *testing.T) {
 	err = Parse(c)
 	g.Expect(err).ShouldNot(HaveOccurred())
 	g.Expect(c.Platform).Should(Equal(VRSPlatform))
+	g.Expect(c.Placement.PriorityClassName).Should(Equal("system-node-critical"))
+	g.Expect(c.Resources.Requests.Memory().String()).Should(Equal(DefaultMemoryRequest))
 
 	c = &operv1.VRSConfigDefinition{
 		Controllers:    []string{"1.1.1.1", "2.2.2.2"},
@@ -56,4 +59,105 @@ func TestParse(t *testing.T) {
 	err = Parse(c)
 	g.Expect(err).Should(HaveOccurred())
 	g.Expect(err.Error()).Should(ContainSubstring("underlay uplink cannot be empty"))
+
+	c = &operv1.VRSConfigDefinition{
+		Controllers:    []string{"1.1.1.1"},
+		UnderlayUplink: "eth0",
+		Placement: operv1.PlacementDefinition{
+			NodeSelector: map[string]string{"nuage.io/no vrs": "true"},
+		},
+	}
+	err = Parse(c)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("invalid node selector key"))
+}
+
+func TestParseSaved(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	//the parsed spec is saved to the cluster and parsed again on the next
+	//reconcile
+	for _, c := range []*operv1.VRSConfigDefinition{
+		{Controllers: []string{"1.1.1.1", "2.2.2.2"}, UnderlayUplink: "eth0"},
+		{ActiveControllers: []string{"1.1.1.1"}, StandbyControllers: []string{"2.2.2.2"}, UnderlayUplink: "eth0"},
+	} {
+		g.Expect(Parse(c)).To(Succeed())
+		data, err := json.Marshal(c)
+		g.Expect(err).NotTo(HaveOccurred())
+		saved := &operv1.VRSConfigDefinition{}
+		g.Expect(json.Unmarshal(data, saved)).To(Succeed())
+		g.Expect(Parse(saved)).To(Succeed())
+		g.Expect(saved).To(Equal(c))
+	}
+}
+
+func TestParseControllerRoles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := &operv1.VRSConfigDefinition{
+		Controllers:    []string{"1.1.1.1", "vsc2.example.com"},
+		UnderlayUplink: "eth0",
+	}
+	err := Parse(c)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	active, standby := Controllers(c)
+	g.Expect(active).Should(Equal([]string{"1.1.1.1"}))
+	g.Expect(standby).Should(Equal([]string{"vsc2.example.com"}))
+
+	//the roles are only rendered, the spec keeps the legacy list
+	g.Expect(c.ActiveControllers).Should(BeEmpty())
+	g.Expect(c.StandbyControllers).Should(BeEmpty())
+	r := RenderConfig(*c)
+	g.Expect(r.ActiveControllers).Should(Equal([]string{"1.1.1.1"}))
+	g.Expect(r.StandbyControllers).Should(Equal([]string{"vsc2.example.com"}))
+	g.Expect(r.Controllers).Should(BeEmpty())
+	g.Expect(c.Controllers).Should(Equal([]string{"1.1.1.1", "vsc2.example.com"}))
+
+	c = &operv1.VRSConfigDefinition{
+		ActiveControllers:  []string{"vsc1.example.com"},
+		StandbyControllers: []string{"vsc2.example.com"},
+		UnderlayUplink:     "eth0",
+	}
+	err = Parse(c)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	c.StandbyControllers = append(c.StandbyControllers, "vsc3.example.com")
+	err = Parse(c)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("2 standby controllers given, vrs supports 1"))
+
+	//the vrs takes one standby, a third legacy controller is not dropped silently
+	c = &operv1.VRSConfigDefinition{
+		Controllers:    []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"},
+		UnderlayUplink: "eth0",
+	}
+	err = Parse(c)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("2 standby controllers given"))
+
+	c = &operv1.VRSConfigDefinition{
+		Controllers:       []string{"1.1.1.1"},
+		ActiveControllers: []string{"2.2.2.2"},
+		UnderlayUplink:    "eth0",
+	}
+	err = Parse(c)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("cannot be used along with active and standby"))
+
+	c = &operv1.VRSConfigDefinition{
+		ActiveControllers: []string{"vsc_1.example.com"},
+		UnderlayUplink:    "eth0",
+	}
+	err = Parse(c)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("controller hostname vsc_1.example.com is not valid"))
+
+	c = &operv1.VRSConfigDefinition{
+		ActiveControllers:  []string{"1.1.1.1"},
+		StandbyControllers: []string{"1.1.1.1"},
+		UnderlayUplink:     "eth0",
+	}
+	err = Parse(c)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("controller 1.1.1.1 is given more than once"))
 }
@@ -5,15 +5,22 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"reflect"
+	"sort"
 
-	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/monitor"
 	log "github.com/sirupsen/logrus"
 	jsonpatch "gopkg.in/evanphx/json-patch.v4"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 //ListNodes fetches the list of nodes from api server that matches listOptions
@@ -27,40 +34,147 @@ func (r *NuageCNIConfigReconciler) ListNodes(listOptions metav1.ListOptions) ([]
 	return nodes.Items, nil
 }
 
-//ListMasterNodes fetches the list of master nodes
-func (r *NuageCNIConfigReconciler) ListMasterNodes() ([]corev1.Node, error) {
-	listOptions := metav1.ListOptions{
-		LabelSelector: "node-role.kubernetes.io/master",
+//ListMasterNodes fetches the list of nodes matching the control plane
+//selectors of the monitor config
+func (r *NuageCNIConfigReconciler) ListMasterNodes(config *operv1.MonitorConfigDefinition) ([]corev1.Node, error) {
+	selectors, err := monitor.ControlPlaneSelectors(config)
+	if err != nil {
+		return []corev1.Node{}, err
 	}
 
-	return r.ListNodes(listOptions)
+	nodes, err := r.ListNodes(metav1.ListOptions{})
+	if err != nil {
+		return []corev1.Node{}, err
+	}
+
+	masters := []corev1.Node{}
+	for _, n := range nodes {
+		if isMasterNode(selectors, &n) {
+			masters = append(masters, n)
+		}
+	}
+	return masters, nil
+}
+
+const (
+	//MasterLabelAnnotation records on a node the master node selector label
+	//the operator added. Labels set by others are never removed
+	MasterLabelAnnotation = "operator.nuage.io/master-node-label"
+)
+
+//LabelMasterNodes keeps the master node selector label of the monitor in
+//sync with the control plane selectors. The label is added to the masters
+//and the labels the operator added are removed from the other nodes and when
+//the selector changes. It returns the sorted names of the masters
+func (r *NuageCNIConfigReconciler) LabelMasterNodes(config *operv1.MonitorConfigDefinition) ([]string, error) {
+	selectors, err := monitor.ControlPlaneSelectors(config)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := r.ListNodes(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	masters := []string{}
+	for i := range nodes {
+		n := &nodes[i]
+		master := isMasterNode(selectors, n)
+		if master {
+			masters = append(masters, n.Name)
+		}
+
+		if err := r.syncMasterLabel(n, config.MasterNodeSelector, master); err != nil {
+			log.Errorf("failed to update node selector label of %s: %v", n.Name, err)
+		}
+	}
+
+	sort.Strings(masters)
+	return masters, nil
+}
+
+// isMasterNode returns true if the node matches any of the selectors
+func isMasterNode(selectors []labels.Selector, n *corev1.Node) bool {
+	for _, s := range selectors {
+		if s.Matches(labels.Set(n.Labels)) {
+			return true
+		}
+	}
+	return false
 }
 
-//LabelMasterNodes labels master nodes with nodeSelector if not already present
-func (r *NuageCNIConfigReconciler) LabelMasterNodes() error {
-	masters, err := r.ListMasterNodes()
+// syncMasterLabel adds the label to a master node that does not have it and
+// records it in the MasterLabelAnnotation. The recorded label is removed
+// when the node is no longer a master or the label changed
+func (r *NuageCNIConfigReconciler) syncMasterLabel(n *corev1.Node, label string, master bool) error {
+	oldData, err := json.Marshal(n)
 	if err != nil {
 		return err
 	}
 
-	for _, m := range masters {
-		if _, ok := m.Labels[names.MasterNodeSelector]; !ok {
-			oldData, _ := json.Marshal(m)
-			m.Labels[names.MasterNodeSelector] = ""
-			newData, _ := json.Marshal(m)
+	added := n.Annotations[MasterLabelAnnotation]
+	if len(added) != 0 && (!master || added != label) {
+		delete(n.Labels, added)
+		delete(n.Annotations, MasterLabelAnnotation)
+	}
+	if _, labelled := n.Labels[label]; master && !labelled {
+		if n.Labels == nil {
+			n.Labels = map[string]string{}
+		}
+		if n.Annotations == nil {
+			n.Annotations = map[string]string{}
+		}
+		n.Labels[label] = ""
+		n.Annotations[MasterLabelAnnotation] = label
+	}
 
-			patch, err := jsonpatch.CreateMergePatch(oldData, newData)
-			if err != nil {
-				log.Errorf("creating patch failed: %v", err)
-				continue
-			}
+	newData, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(oldData, newData) {
+		return nil
+	}
 
-			_, err = r.clientset.CoreV1().Nodes().Patch(context.TODO(), m.Name, types.MergePatchType, patch, metav1.PatchOptions{})
-			if err != nil {
-				log.Errorf("failed to add node selector label to %s: %v", m.Name, err)
+	patch, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.clientset.CoreV1().Nodes().Patch(context.TODO(), n.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// nodeLabelsChanged filters the node events that can change the masters
+func nodeLabelsChanged() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !reflect.DeepEqual(e.MetaOld.GetLabels(), e.MetaNew.GetLabels())
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+//ListNodeAddresses fetches the internal ip addresses of all nodes
+func (r *NuageCNIConfigReconciler) ListNodeAddresses() ([]HostAddress, error) {
+	nodes, err := r.ListNodes(metav1.ListOptions{})
+	if err != nil {
+		return []HostAddress{}, err
+	}
+
+	hosts := []HostAddress{}
+	for _, n := range nodes {
+		for _, addr := range n.Status.Addresses {
+			if addr.Type != corev1.NodeInternalIP {
+				continue
 			}
+			hosts = append(hosts, HostAddress{
+				Name:    "node " + n.Name,
+				Address: addr.Address,
+			})
 		}
 	}
 
-	return nil
+	return hosts, nil
 }
@@ -5,8 +5,11 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -50,7 +53,7 @@ func TestNodesList(t *testing.T) {
 func TestNodesListMasters(t *testing.T) {
 	initData(t)
 
-	obs, err := r.ListMasterNodes()
+	obs, err := r.ListMasterNodes(&operv1.MonitorConfigDefinition{})
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(len(obs)).To(Equal(1))
 }
@@ -58,6 +61,65 @@ func TestNodesListMasters(t *testing.T) {
 func TestNodesLabelMasters(t *testing.T) {
 	initData(t)
 
-	err := r.LabelMasterNodes()
+	config := &operv1.MonitorConfigDefinition{MasterNodeSelector: names.MasterNodeSelector}
+	masters, err := r.LabelMasterNodes(config)
 	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(masters).To(Equal([]string{"node1"}))
+	g.Expect(nodeLabels("node1")).To(HaveKey(names.MasterNodeSelector))
+	g.Expect(nodeLabels("node2")).NotTo(HaveKey(names.MasterNodeSelector))
+
+	//node2 becomes a control plane node and node1 is demoted
+	config.ControlPlaneSelector = "node-role.kubernetes.io/control-plane"
+	n := exp[1].DeepCopy()
+	n.Labels = map[string]string{"node-role.kubernetes.io/control-plane": ""}
+	_, err = r.clientset.CoreV1().Nodes().Update(context.TODO(), n, metav1.UpdateOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	masters, err = r.LabelMasterNodes(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(masters).To(Equal([]string{"node2"}))
+	g.Expect(nodeLabels("node1")).NotTo(HaveKey(names.MasterNodeSelector))
+	g.Expect(nodeLabels("node2")).To(HaveKey(names.MasterNodeSelector))
+}
+
+func TestNodesLabelMastersOwnership(t *testing.T) {
+	initData(t)
+
+	//an admin labelled node2 as a monitor node
+	n := exp[1].DeepCopy()
+	n.Labels = map[string]string{names.MasterNodeSelector: ""}
+	_, err := r.clientset.CoreV1().Nodes().Update(context.TODO(), n, metav1.UpdateOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	config := &operv1.MonitorConfigDefinition{MasterNodeSelector: names.MasterNodeSelector}
+	masters, err := r.LabelMasterNodes(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(masters).To(Equal([]string{"node1"}))
+	g.Expect(nodeLabels("node1")).To(HaveKey(names.MasterNodeSelector))
+	g.Expect(nodeAnnotations("node1")).To(HaveKeyWithValue(MasterLabelAnnotation, names.MasterNodeSelector))
+	g.Expect(nodeLabels("node2")).To(HaveKey(names.MasterNodeSelector))
+	g.Expect(nodeAnnotations("node2")).NotTo(HaveKey(MasterLabelAnnotation))
+
+	//the label the operator added moves to the new selector
+	config.MasterNodeSelector = "example.com/nuage-monitor"
+	masters, err = r.LabelMasterNodes(config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(masters).To(Equal([]string{"node1"}))
+	g.Expect(nodeLabels("node1")).NotTo(HaveKey(names.MasterNodeSelector))
+	g.Expect(nodeLabels("node1")).To(HaveKey("example.com/nuage-monitor"))
+	g.Expect(nodeAnnotations("node1")).To(HaveKeyWithValue(MasterLabelAnnotation, "example.com/nuage-monitor"))
+	g.Expect(nodeLabels("node2")).To(HaveKey(names.MasterNodeSelector))
+	g.Expect(nodeLabels("node2")).NotTo(HaveKey("example.com/nuage-monitor"))
+}
+
+func nodeAnnotations(name string) map[string]string {
+	n, err := r.clientset.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	return n.Annotations
+}
+
+func nodeLabels(name string) map[string]string {
+	n, err := r.clientset.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	return n.Labels
 }
@@ -23,27 +23,30 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"strings"
 
 	"github.com/nuagenetworks/nuage-network-operator/controllers/certs"
-	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
 	"github.com/nuagenetworks/nuage-network-operator/controllers/network/cni"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/infra"
 	"github.com/nuagenetworks/nuage-network-operator/controllers/network/monitor"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/proxy"
 	"github.com/nuagenetworks/nuage-network-operator/controllers/network/vrs"
 	"github.com/nuagenetworks/nuage-network-operator/controllers/render"
+	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/api/network"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -53,14 +56,16 @@ import (
 
 var (
 	//ManifestPath is the path to templates directory
-	ManifestPath   = "./bindata"
-	monitDaemonset = types.NamespacedName{
-		Namespace: names.Namespace,
-		Name:      names.NuageMonitor,
-	}
+	ManifestPath = "./bindata"
 )
 
-const nuageFinalizer = "finalizer.operator.nuage.io"
+const (
+	nuageFinalizer = "finalizer.operator.nuage.io"
+	//PausedAnnotation stops the operator from changing the cluster when set to
+	//"true". Unlike managementState Unmanaged it also holds the teardown of a
+	//deleted config, the finalizer stays until the annotation is removed
+	PausedAnnotation = "operator.nuage.io/paused"
+)
 
 // OrchestratorType is for orchestrator type(k8s or ose)
 type OrchestratorType string
@@ -80,8 +85,9 @@ type NuageCNIConfigReconciler struct {
 	orchestrator               OrchestratorType
 	clusterNetworkCIDR         string
 	apiServerURL               string
-	serviceAccountToken        []byte
+	clusterCA                  string
 	clusterNetworkSubnetLength uint32
+	vsdCheck                   vsdCheck
 	clientset                  kubernetes.Interface
 	ClusterServiceNetworkCIDR  string
 	client.Client
@@ -91,6 +97,26 @@ type NuageCNIConfigReconciler struct {
 
 // +kubebuilder:rbac:groups=operator.nuage.io,resources=nuagecniconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=operator.nuage.io,resources=nuagecniconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;clusterrolebindings,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=networking.k8s.io;extensions,resources=networkpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=project.openshift.io,resources=projects,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=list
+// +kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get;list;watch
+// +kubebuilder:rbac:groups=config.openshift.io,resources=proxies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=config.openshift.io,resources=networks,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=config.openshift.io,resources=networks/status,verbs=update
+// +kubebuilder:rbac:groups=config.openshift.io,resources=clusteroperators,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=config.openshift.io,resources=clusteroperators/status,verbs=update
 
 func (r *NuageCNIConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	_ = context.Background()
@@ -110,6 +136,24 @@ func (r *NuageCNIConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		}
 		return reconcile.Result{}, err
 	}
+
+	state := getManagementState(instance)
+	//Publish the outcome of this reconcile in the cluster operator
+	defer func() {
+		if err := r.UpdateClusterOperator(instance, state); err != nil {
+			log.Errorf("updating the cluster operator failed %v", err)
+		}
+	}()
+
+	if err := r.SetManagementState(instance, state); err != nil {
+		log.Errorf("updating status failed %v", err)
+		return reconcile.Result{}, err
+	}
+	if isReconcileHeld(instance, state) {
+		log.Infof("NuageCNIConfig is unmanaged or paused, not reconciling")
+		return reconcile.Result{}, nil
+	}
+
 	if err := r.parse(instance); err != nil {
 		log.Errorf("failed to parse crd config %v", err)
 		return reconcile.Result{}, err
@@ -119,22 +163,45 @@ func (r *NuageCNIConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		r.setPodNetworkConfig(&instance.Spec.PodNetworkConfig)
 	}
 
+	removing := instance.GetDeletionTimestamp() != nil || state == operatorv1alpha1.ManagementStateRemoved
+
 	clusterInfo, err := r.GetClusterNetworkInfo()
-	if err != nil {
+	if err == nil && r.orchestrator == OrchestratorKubernetes {
+		err = r.ValidateK8SClusterNetwork(clusterInfo, &instance.Spec.VRSConfig)
+	}
+	if err == nil && clusterInfo != nil {
+		clusterInfo.ClusterNetworkMTU, err = r.GetClusterNetworkMTU(&instance.Spec)
+	}
+	clusterInfo, err = removableClusterNetwork(clusterInfo, err, removing)
+	if cerr, ok := err.(*ClusterNetworkError); ok {
+		log.Errorf("cluster network config cannot be used %v", cerr)
+		return reconcile.Result{}, r.SetDegraded(instance, cerr.Reason, cerr.Message)
+	} else if err != nil {
 		log.Errorf("failed to get cluster network config %v", err)
 		return reconcile.Result{}, err
 	}
 
-	if clusterInfo == nil {
-		log.Infof("could not populate network config")
-		return reconcile.Result{}, nil
+	var monitorIP string
+	if !removing {
+		if monitorIP, err = r.ReconcileMonitorService(&instance.Spec.MonitorConfig); err != nil {
+			log.Errorf("reconciling the monitor service failed %v", err)
+			return reconcile.Result{}, err
+		}
+	}
+	//The derived url is only rendered, it is not saved in the spec
+	spec := instance.Spec
+	spec.VRSConfig = vrs.RenderConfig(spec.VRSConfig)
+	spec.MonitorConfig = monitor.RenderConfig(spec.MonitorConfig)
+	if len(spec.CNIConfig.LoadBalancerURL) == 0 && len(monitorIP) != 0 {
+		spec.CNIConfig.LoadBalancerURL = monitorServiceURL(monitorIP, spec.MonitorConfig.RestServerPort)
 	}
 
+	hosts := monitorHosts(monitorIP, spec.CNIConfig.LoadBalancerURL)
 	certificates := &operatorv1alpha1.TLSCertificates{}
-	if err := r.GetConfigFromServer(certConfig, certificates); err == nil && certificates.CA == nil {
-		log.Infof("No previous certificates found. creating certs first time")
+	if err := r.GetConfigFromServer(certConfig, certificates); err == nil && (certificates.CA == nil || !certs.CoversHosts(certificates, hosts)) {
+		log.Infof("No previous certificates found for %v. creating certs", hosts)
 
-		certificates, err = certs.GenerateCertificates(&operatorv1alpha1.CertGenConfig{})
+		certificates, err = certs.GenerateCertificates(&operatorv1alpha1.CertGenConfig{Hosts: hosts})
 		if err != nil {
 			log.Errorf("failed to generate certs %v", err)
 			return reconcile.Result{}, err
@@ -149,13 +216,58 @@ func (r *NuageCNIConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		return reconcile.Result{}, err
 	}
 
+	apiServerURL, err := r.GetAPIServerURL(&instance.Spec.CNIConfig)
+	if err != nil {
+		log.Errorf("discovering the api server url failed %v", err)
+		return reconcile.Result{}, err
+	}
+
+	proxyConfig, err := r.GetProxyConfig(&instance.Spec, clusterInfo, apiServerURL)
+	if err != nil {
+		log.Errorf("getting the proxy config failed %v", err)
+		return reconcile.Result{}, err
+	}
+
+	//The kubeconfig is not needed to tear the components down
+	clusterCA, err := r.GetClusterCA()
+	if err != nil && !removing {
+		log.Errorf("getting the cluster ca failed %v", err)
+		if serr := r.SetDegraded(instance, ReasonClusterCAUnavailable, err.Error()); serr != nil {
+			log.Errorf("updating status failed %v", serr)
+		}
+		return reconcile.Result{}, err
+	}
+
+	var vsdCA string
+	if !removing {
+		if vsdCA, err = r.GetVSDCA(&instance.Spec.MonitorConfig.VSDCA); err != nil {
+			log.Errorf("getting the vsd ca failed %v", err)
+			if serr := r.SetDegraded(instance, ReasonVSDCAUnavailable, err.Error()); serr != nil {
+				log.Errorf("updating status failed %v", serr)
+			}
+			return reconcile.Result{}, err
+		}
+		if err := r.CheckVSDCertificate(instance, vsdCA, proxyConfig); err != nil {
+			log.Errorf("updating status failed %v", err)
+			return reconcile.Result{}, err
+		}
+	}
+	//The monitor takes a single VSD url. It is pointed at a healthy
+	//endpoint, the config hash rolls it when that one goes down
+	active := activeVSDEndpoint(monitor.VSDEndpoints(&spec.MonitorConfig), instance.Status.VSDEndpoints)
+	spec.MonitorConfig.VSDAddress, spec.MonitorConfig.VSDPort = active.Address, active.Port
+
 	//Render the templates and get the objects
 	renderData := render.MakeRenderData(&operatorv1alpha1.RenderConfig{
-		NuageCNIConfigSpec:   instance.Spec,
-		K8SAPIServerURL:      r.apiServerURL,
-		ServiceAccountToken:  string(r.serviceAccountToken),
+		NuageCNIConfigSpec:   spec,
+		Orchestrator:         string(r.orchestrator),
+		K8SAPIServerURL:      apiServerURL,
+		ClusterCA:            clusterCA,
+		VSDCA:                vsdCA,
 		Certificates:         certificates,
 		ClusterNetworkConfig: clusterInfo,
+		Proxy:                proxyConfig,
+		HostCleanup:          removing && instance.Spec.DeletionPolicy == operatorv1alpha1.DeletionPolicyCleanupHost,
 	})
 
 	var objs []*unstructured.Unstructured
@@ -169,36 +281,22 @@ func (r *NuageCNIConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		// Run finalization logic for nuageFinalizer. If the
 		// finalization logic fails, don't remove the finalizer so
 		// that we can retry during the next reconciliation.
-		nuage_crd_names := []string{"nuage-infra", "nuage-monitor", "nuage-cni", "nuage-vrs"}
-		for _, nuage_crd_name := range nuage_crd_names {
-			err = r.deleteNuageResourceByName(objs, nuage_crd_name)
-			if err != nil {
-				return reconcile.Result{}, err
-			} else {
-				log.Infof("Deleted %s CRD objects", nuage_crd_name)
-			}
-			if nuage_crd_name == "nuage-infra" {
-				err = r.confirmPodsDeletion(nuage_crd_name)
-				if err != nil {
-					return reconcile.Result{}, err
-				}
-			}
-		}
+		return r.ReconcileDeletion(instance, objs)
+	}
 
-		// Remove nuageFinalizer.
-		instance.SetFinalizers(nil)
+	if state == operatorv1alpha1.ManagementStateRemoved {
+		return r.ReconcileRemoval(instance, objs)
+	}
 
-		// Update CR
-		err = r.Client.Update(context.TODO(), instance)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-		return reconcile.Result{}, nil
+	if err := r.ClearDeletionStatus(instance); err != nil {
+		log.Errorf("updating status failed %v", err)
+		return reconcile.Result{}, err
 	}
 
-	monitVSDAddressChange, err := r.checkMonitVSDAddressChange(instance)
-	if err != nil {
-		return reconcile.Result{}, nil
+	//Roll the daemonsets when the config they consume changes
+	if err := SetConfigHashAnnotations(objs); err != nil {
+		log.Errorf("setting config hash annotations failed %v", err)
+		return reconcile.Result{}, err
 	}
 
 	//Create or update the objects against API server
@@ -213,8 +311,17 @@ func (r *NuageCNIConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		}
 	}
 
-	if err := r.LabelMasterNodes(); err != nil {
+	if !instance.Spec.RBAC.LegacyTokenEnv {
+		if err := r.DeleteCNITokenSecret(); err != nil {
+			log.Errorf("deleting the legacy cni token secret failed %v", err)
+		}
+	}
+
+	if masters, err := r.LabelMasterNodes(&instance.Spec.MonitorConfig); err != nil {
 		log.Errorf("labeling master node with selector failed %v", err)
+	} else if err := r.SetMasterNodes(instance, masters); err != nil {
+		log.Errorf("updating status failed %v", err)
+		return reconcile.Result{}, err
 	}
 
 	if err := r.SaveConfigToServer(releaseConfig, &instance.Spec.ReleaseConfig); err != nil {
@@ -228,18 +335,17 @@ func (r *NuageCNIConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, err
 		return reconcile.Result{}, err
 	}
 
-	if monitVSDAddressChange {
-		if err = r.UpdateDaemonsetpods(monitDaemonset); err != nil {
-			log.Errorf("Updating daemonset pods failed %v", err)
-			return reconcile.Result{}, err
-		}
-	}
-
 	// Add finalizer for this CR
 	if err := r.addFinalizer(instance); err != nil {
 		return reconcile.Result{}, err
 	}
-	return ctrl.Result{}, nil
+
+	if err := r.ClearDegraded(instance); err != nil {
+		log.Errorf("updating status failed %v", err)
+		return reconcile.Result{}, err
+	}
+	//Recheck the VSD certificate before it expires unnoticed
+	return ctrl.Result{RequeueAfter: VSDCheckInterval}, nil
 }
 
 func (r *NuageCNIConfigReconciler) deleteNuageResourceByName(objs []*unstructured.Unstructured, objName string) error {
@@ -260,58 +366,6 @@ func (r *NuageCNIConfigReconciler) deleteNuageResourceByName(objs []*unstructure
 	return nil
 }
 
-func (r *NuageCNIConfigReconciler) checkMonitVSDAddressChange(instance *operatorv1alpha1.NuageCNIConfig) (bool, error) {
-	monitConfigMap, err := r.GetConfigMap(monitConfig)
-	if err == nil {
-		for _, monitConfigData := range monitConfigMap.Data {
-			for _, lineData := range strings.Split(monitConfigData, "\n") {
-				re, err := regexp.Compile(`vsdApiUrl`)
-				match := re.FindStringIndex(lineData)
-				if match != nil {
-					currentAddress := strings.Split(lineData, "//")[len(strings.Split(lineData, "//"))-1]
-					newAddress := fmt.Sprintf("%s:%d", instance.Spec.MonitorConfig.VSDAddress, instance.Spec.MonitorConfig.VSDPort)
-					if currentAddress != newAddress {
-						log.Infof("Current VSDAddress %s to be updated to %s", currentAddress, newAddress)
-						return true, nil
-					}
-				} else if err != nil {
-					log.Errorf("Error finding VSDURL in configMap %v", err)
-				}
-			}
-		}
-	} else if apierrors.IsNotFound(err) {
-		log.Infof("No previous monitor configMap found, a new configmap will be created")
-	} else {
-		log.Errorf("Error getting monit configMap %v", err)
-		return false, err
-	}
-	return false, nil
-}
-
-func (r *NuageCNIConfigReconciler) confirmPodsDeletion(CRDName string) error {
-	log.Infof("Waiting for pods related to %s be deleted", CRDName)
-	for {
-		CRDPodsDeleted := true
-		podList, err := r.clientset.CoreV1().Pods(names.Namespace).List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			log.Errorf("Cannot retrieve pods from namespace %s: %s", names.Namespace, err)
-			return err
-		}
-		for _, pod := range podList.Items {
-			if pod.ObjectMeta.GenerateName == CRDName+"-" {
-				CRDPodsDeleted = false
-				break
-			}
-		}
-		if CRDPodsDeleted {
-			break
-		} else {
-			continue
-		}
-	}
-	return nil
-}
-
 func (r *NuageCNIConfigReconciler) addFinalizer(nuageOperator *operatorv1alpha1.NuageCNIConfig) error {
 	if len(nuageOperator.GetFinalizers()) < 1 && nuageOperator.GetDeletionTimestamp() == nil {
 		log.Infof("Adding Finalizer for the Nuage")
@@ -326,6 +380,28 @@ func (r *NuageCNIConfigReconciler) addFinalizer(nuageOperator *operatorv1alpha1.
 	return nil
 }
 
+// getManagementState returns the management state of the instance, the
+// paused annotation takes precedence over the spec
+func getManagementState(instance *operatorv1alpha1.NuageCNIConfig) operatorv1alpha1.ManagementState {
+	if instance.GetAnnotations()[PausedAnnotation] == "true" {
+		return operatorv1alpha1.ManagementStateUnmanaged
+	}
+	if len(instance.Spec.ManagementState) == 0 {
+		return operatorv1alpha1.ManagementStateManaged
+	}
+	return instance.Spec.ManagementState
+}
+
+// isReconcileHeld returns true when the operator must not change the
+// cluster. An unmanaged instance is still torn down when it is deleted, a
+// paused one is not
+func isReconcileHeld(instance *operatorv1alpha1.NuageCNIConfig, state operatorv1alpha1.ManagementState) bool {
+	if instance.GetAnnotations()[PausedAnnotation] == "true" {
+		return true
+	}
+	return state == operatorv1alpha1.ManagementStateUnmanaged && instance.GetDeletionTimestamp() == nil
+}
+
 func (r *NuageCNIConfigReconciler) getOrchestratorType() (OrchestratorType, error) {
 
 	if r.dclient == nil {
@@ -346,6 +422,13 @@ func (r *NuageCNIConfigReconciler) getOrchestratorType() (OrchestratorType, erro
 }
 
 func (r *NuageCNIConfigReconciler) parse(instance *operatorv1alpha1.NuageCNIConfig) error {
+	if r.orchestrator == OrchestratorOpenShift {
+		if err := monitor.ValidateOpenShift(&instance.Spec.MonitorConfig); err != nil {
+			log.Errorf("Failed to parse monitor config %v", err)
+			return err
+		}
+	}
+
 	if err := monitor.Parse(&instance.Spec.MonitorConfig); err != nil {
 		//invalid config passed.
 		// TODO: update the operator status to the same and don't requeue
@@ -353,7 +436,7 @@ func (r *NuageCNIConfigReconciler) parse(instance *operatorv1alpha1.NuageCNIConf
 		return err
 	}
 
-	if err := cni.Parse(&instance.Spec.CNIConfig); err != nil {
+	if err := cni.Parse(&instance.Spec.CNIConfig, &instance.Spec.MonitorConfig); err != nil {
 		//invalid config passed.
 		//TODO: update the operator status to the same and don't requeue
 		log.Errorf("Failed to parse cni config %v", err)
@@ -366,6 +449,18 @@ func (r *NuageCNIConfigReconciler) parse(instance *operatorv1alpha1.NuageCNIConf
 		log.Errorf("Failed to parse vrs config %v", err)
 		return err
 	}
+
+	if err := infra.Parse(&instance.Spec.InfraConfig, &instance.Spec.MonitorConfig.VSDMetadata); err != nil {
+		//invalid config passed.
+		//TODO: update the operator status to the same and don't requeue
+		log.Errorf("Failed to parse infra config %v", err)
+		return err
+	}
+
+	if err := proxy.Parse(&instance.Spec.Proxy); err != nil {
+		log.Errorf("Failed to parse proxy config %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -375,22 +470,6 @@ func (r *NuageCNIConfigReconciler) setPodNetworkConfig(p *operatorv1alpha1.PodNe
 	r.ClusterServiceNetworkCIDR = p.ClusterServiceNetworkCIDR
 }
 
-func (r *NuageCNIConfigReconciler) getServiceAccountToken() ([]byte, error) {
-	secret, err := r.GetSecret(names.ServiceAccountName, names.Namespace)
-	if err != nil {
-		log.Errorf("Failed to get secret for sa %s in ns %s", names.ServiceAccountName, names.Namespace)
-		return []byte{}, err
-	}
-
-	token, err := r.ExtractSecretToken(secret)
-	if err != nil {
-		log.Errorf("Token extraction failed %v", err)
-		return []byte{}, err
-	}
-
-	return token, nil
-}
-
 func (r *NuageCNIConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
 	if err != nil {
@@ -413,15 +492,42 @@ func (r *NuageCNIConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	r.serviceAccountToken, err = r.getServiceAccountToken()
+	r.apiServerURL = mgr.GetConfig().Host
+
+	r.clusterCA, err = restConfigCA(mgr.GetConfig())
 	if err != nil {
-		log.Errorf("creating service account token failed %v", err)
+		log.Errorf("reading the api server ca failed %v", err)
 		return err
 	}
 
-	r.apiServerURL = mgr.GetConfig().Host
+	//Follow the control plane endpoints the api server url is derived from
+	endpoints := r.apiServerEndpointsWatch()
+	if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		endpoints.Run(stop)
+		return nil
+	})); err != nil {
+		log.Errorf("adding the api server endpoints watch failed %v", err)
+		return err
+	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&operatorv1alpha1.NuageCNIConfig{}).
-		Complete(r)
+		Watches(&source.Informer{Informer: endpoints}, r.enqueueAll()).
+		Watches(&source.Kind{Type: &corev1.Node{}}, r.enqueueAll(), builder.WithPredicates(nodeLabelsChanged()))
+	if r.orchestrator == OrchestratorOpenShift {
+		//Follow the rollout of the daemonsets reported in the cluster operator
+		daemonSets := r.daemonSetsWatch()
+		if err := mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			daemonSets.Run(stop)
+			return nil
+		})); err != nil {
+			log.Errorf("adding the daemonsets watch failed %v", err)
+			return err
+		}
+		b = b.Watches(&source.Kind{Type: &configv1.Infrastructure{}}, r.enqueueAll()).
+			Watches(&source.Kind{Type: &configv1.Proxy{}}, r.enqueueAll()).
+			Watches(&source.Kind{Type: &configv1.Network{}}, r.enqueueAll(), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+			Watches(&source.Informer{Informer: daemonSets}, r.enqueueAll())
+	}
+	return b.Complete(r)
 }
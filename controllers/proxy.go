@@ -0,0 +1,108 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"net/url"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/proxy"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	//ProxyName is the name of the openshift cluster proxy config
+	ProxyName = "cluster"
+	//TrustedCAConfigMap is filled with the trusted ca bundle of the cluster on openshift
+	TrustedCAConfigMap = "nuage-trusted-ca"
+	//InjectTrustedCABundleLabel asks the cluster network operator to fill a config map with the trusted ca bundle
+	InjectTrustedCABundleLabel = "config.openshift.io/inject-trusted-cabundle"
+)
+
+//GetProxyConfig returns the proxy of the monitor and infra pods. It is read
+//from the cluster proxy config on openshift and from the spec otherwise. The
+//pod, service and node networks, the api server and etcd are added to the no
+//proxy list
+func (r *NuageCNIConfigReconciler) GetProxyConfig(spec *operv1.NuageCNIConfigSpec, c *operv1.ClusterNetworkConfigDefinition, apiServerURL string) (operv1.ClusterProxyConfig, error) {
+	p := operv1.ClusterProxyConfig{}
+	var nodes []string
+
+	if r.orchestrator == OrchestratorOpenShift {
+		//the status holds the effective proxy, its no proxy list already
+		//includes the machine networks
+		clusterProxy := &configv1.Proxy{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: ProxyName}, clusterProxy)
+		if apierrors.IsNotFound(err) {
+			return p, nil
+		} else if err != nil {
+			return p, err
+		}
+		p.HTTPProxy = clusterProxy.Status.HTTPProxy
+		p.HTTPSProxy = clusterProxy.Status.HTTPSProxy
+		p.NoProxy = clusterProxy.Status.NoProxy
+		if len(clusterProxy.Spec.TrustedCA.Name) != 0 {
+			if err := r.createTrustedCAConfigMap(); err != nil {
+				return p, err
+			}
+			p.TrustedCAConfigMap = TrustedCAConfigMap
+		}
+	} else {
+		p.HTTPProxy = spec.Proxy.HTTPProxy
+		p.HTTPSProxy = spec.Proxy.HTTPSProxy
+		p.NoProxy = spec.Proxy.NoProxy
+		if spec.Proxy.TrustedCA != nil {
+			p.TrustedCAConfigMap = spec.Proxy.TrustedCA.Name
+		}
+	}
+
+	if len(p.HTTPProxy) == 0 && len(p.HTTPSProxy) == 0 {
+		p.NoProxy = ""
+		return p, nil
+	}
+
+	if r.orchestrator == OrchestratorKubernetes {
+		hosts, err := r.ListNodeAddresses()
+		if err != nil {
+			return p, err
+		}
+		for _, h := range hosts {
+			nodes = append(nodes, h.Address)
+		}
+	}
+
+	entries := []string{c.ClusterNetworkCIDR, c.ServiceNetworkCIDR}
+	//the api server and etcd may be reached through node addresses or names
+	for _, s := range append([]string{apiServerURL}, spec.MonitorConfig.Etcd.Endpoints...) {
+		if u, err := url.Parse(s); err == nil && len(u.Hostname()) != 0 {
+			entries = append(entries, u.Hostname())
+		}
+	}
+	p.NoProxy = proxy.NoProxy(p.NoProxy, append(entries, nodes...)...)
+	return p, nil
+}
+
+// createTrustedCAConfigMap creates the config map the cluster network operator
+// injects the trusted ca bundle in. It is never updated, that would drop the
+// injected bundle
+func (r *NuageCNIConfigReconciler) createTrustedCAConfigMap() error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TrustedCAConfigMap,
+			Namespace: names.Namespace,
+			Labels:    map[string]string{InjectTrustedCABundleLabel: "true"},
+		},
+	}
+	_, err := r.clientset.CoreV1().ConfigMaps(names.Namespace).Create(context.TODO(), cm, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var proxyClusterNetwork = &operv1.ClusterNetworkConfigDefinition{
+	ClusterNetworkCIDR: "10.128.0.0/14",
+	ServiceNetworkCIDR: "172.30.0.0/16",
+}
+
+func TestGetProxyConfigK8S(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-0"},
+		Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "192.168.1.20"},
+			{Type: corev1.NodeExternalIP, Address: "203.0.113.20"},
+		}},
+	}
+	r := &NuageCNIConfigReconciler{
+		Client:       fake.NewFakeClient(),
+		clientset:    kubefake.NewSimpleClientset(node),
+		orchestrator: OrchestratorKubernetes,
+	}
+	spec := &operv1.NuageCNIConfigSpec{}
+	spec.MonitorConfig.Etcd.Endpoints = []string{"https://127.0.0.1:2379", "https://etcd-0.example.com:2379"}
+
+	//the no proxy list alone is not rendered
+	spec.Proxy.NoProxy = "example.com"
+	p, err := r.GetProxyConfig(spec, proxyClusterNetwork, "https://192.168.1.10:6443")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p).To(Equal(operv1.ClusterProxyConfig{}))
+
+	spec.Proxy.HTTPSProxy = "http://proxy.example.com:3128"
+	spec.Proxy.TrustedCA = &corev1.LocalObjectReference{Name: "corporate-ca"}
+	p, err = r.GetProxyConfig(spec, proxyClusterNetwork, "https://192.168.1.10:6443")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p).To(Equal(operv1.ClusterProxyConfig{
+		HTTPSProxy: "http://proxy.example.com:3128",
+		NoProxy: "example.com,localhost,127.0.0.1,.svc,.cluster.local,10.128.0.0/14,172.30.0.0/16," +
+			"192.168.1.10,etcd-0.example.com,192.168.1.20",
+		TrustedCAConfigMap: "corporate-ca",
+	}))
+}
+
+func TestGetProxyConfigOSE(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		Client:       fake.NewFakeClient(),
+		clientset:    kubefake.NewSimpleClientset(),
+		orchestrator: OrchestratorOpenShift,
+	}
+	spec := &operv1.NuageCNIConfigSpec{}
+	//the spec is ignored on openshift
+	spec.Proxy.HTTPProxy = "http://ignored.example.com:3128"
+
+	p, err := r.GetProxyConfig(spec, proxyClusterNetwork, "https://api-int.example.com:6443")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p).To(Equal(operv1.ClusterProxyConfig{}))
+
+	clusterProxy := &configv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{Name: ProxyName},
+		Spec: configv1.ProxySpec{
+			HTTPProxy: "http://proxy.example.com:3128",
+			TrustedCA: configv1.ConfigMapNameReference{Name: "user-ca-bundle"},
+		},
+		Status: configv1.ProxyStatus{
+			HTTPProxy: "http://proxy.example.com:3128",
+			NoProxy:   ".cluster.local,.svc,10.128.0.0/14,192.168.1.0/24,api-int.example.com,localhost",
+		},
+	}
+	g.Expect(r.Client.Create(context.TODO(), clusterProxy)).To(Succeed())
+
+	p, err = r.GetProxyConfig(spec, proxyClusterNetwork, "https://api-int.example.com:6443")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p).To(Equal(operv1.ClusterProxyConfig{
+		HTTPProxy:          "http://proxy.example.com:3128",
+		NoProxy:            ".cluster.local,.svc,10.128.0.0/14,192.168.1.0/24,api-int.example.com,localhost,127.0.0.1,172.30.0.0/16",
+		TrustedCAConfigMap: TrustedCAConfigMap,
+	}))
+
+	cm, err := r.clientset.CoreV1().ConfigMaps(names.Namespace).Get(context.TODO(), TrustedCAConfigMap, metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Labels).To(HaveKeyWithValue(InjectTrustedCABundleLabel, "true"))
+
+	//the injected bundle is left alone
+	cm.Data = map[string]string{"ca-bundle.crt": "bundle"}
+	_, err = r.clientset.CoreV1().ConfigMaps(names.Namespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = r.GetProxyConfig(spec, proxyClusterNetwork, "https://api-int.example.com:6443")
+	g.Expect(err).NotTo(HaveOccurred())
+	cm, err = r.clientset.CoreV1().ConfigMaps(names.Namespace).Get(context.TODO(), TrustedCAConfigMap, metav1.GetOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cm.Data).To(HaveKeyWithValue("ca-bundle.crt", "bundle"))
+}
@@ -0,0 +1,332 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package render
+
+import (
+	"strings"
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const bindataPath = "../../bindata"
+
+// bindataConfig returns a render config that renders every manifest
+// under bindata
+func bindataConfig() *operv1.RenderConfig {
+	s := func(v string) *string { return &v }
+
+	c := &operv1.RenderConfig{
+		Certificates: &operv1.TLSCertificates{
+			CA:          s("ca"),
+			Certificate: s("cert"),
+			PrivateKey:  s("key"),
+		},
+		ClusterNetworkConfig: &operv1.ClusterNetworkConfigDefinition{
+			ClusterNetworkCIDR:         "70.70.0.0/16",
+			ServiceNetworkCIDR:         "10.96.0.0/12",
+			ClusterNetworkSubnetLength: 24,
+			ClusterNetworkMTU:          1450,
+		},
+		HostCleanup: true,
+	}
+	c.VRSConfig.ActiveControllers = []string{"10.0.0.2"}
+	c.MonitorConfig.ClusterRoleName = "nuage-monitor"
+	c.CNIConfig.ClusterRoleName = "nuage-cni"
+	c.CNIConfig.ServiceAccountName = "nuage-cni"
+	return c
+}
+
+// wildcardRules returns the rules of the rendered roles that contain a wildcard
+func wildcardRules(objs []*unstructured.Unstructured) []string {
+	found := []string{}
+	for _, obj := range objs {
+		if obj.GetKind() != "ClusterRole" && obj.GetKind() != "Role" {
+			continue
+		}
+		rules, _, _ := unstructured.NestedSlice(obj.Object, "rules")
+		for _, rule := range rules {
+			for _, field := range []string{"apiGroups", "resources", "verbs", "nonResourceURLs"} {
+				values, _, _ := unstructured.NestedStringSlice(rule.(map[string]interface{}), field)
+				for _, v := range values {
+					if v == "*" {
+						found = append(found, obj.GetName()+" "+field)
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+func TestRenderBindataRoles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := bindataConfig()
+	d := MakeRenderData(c)
+	objs, err := RenderDir(bindataPath, &d)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(wildcardRules(objs)).To(BeEmpty())
+
+	c.RBAC.LegacyWildcard = true
+	objs, err = RenderDir(bindataPath, &d)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(wildcardRules(objs)).To(ContainElement("nuage-monitor verbs"))
+	g.Expect(wildcardRules(objs)).To(ContainElement("nuage-cni verbs"))
+}
+
+func TestRenderBindataServiceAccountToken(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	render := func(c *operv1.RenderConfig) (*unstructured.Unstructured, map[string]interface{}, *unstructured.Unstructured) {
+		d := MakeRenderData(c)
+		objs, err := RenderDir(bindataPath, &d)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		var cni, secret *unstructured.Unstructured
+		for _, obj := range objs {
+			if obj.GetKind() == "DaemonSet" && obj.GetName() == "nuage-cni" {
+				cni = obj
+			}
+			if obj.GetKind() == "Secret" {
+				secret = obj
+			}
+		}
+		g.Expect(cni).NotTo(BeNil())
+
+		containers, _, _ := unstructured.NestedSlice(cni.Object, "spec", "template", "spec", "containers")
+		env, _, _ := unstructured.NestedSlice(containers[0].(map[string]interface{}), "env")
+		var token map[string]interface{}
+		for _, e := range env {
+			if e.(map[string]interface{})["name"] == "NUAGE_TOKEN" {
+				token = e.(map[string]interface{})
+			}
+		}
+		return cni, token, secret
+	}
+
+	//only the rotated token is mounted by default
+	cni, token, secret := render(bindataConfig())
+	g.Expect(token).To(BeNil())
+	g.Expect(secret).To(BeNil())
+
+	volumes, _, _ := unstructured.NestedSlice(cni.Object, "spec", "template", "spec", "volumes")
+	var sources []interface{}
+	for _, v := range volumes {
+		if v.(map[string]interface{})["name"] == "nuage-token" {
+			sources, _, _ = unstructured.NestedSlice(v.(map[string]interface{}), "projected", "sources")
+		}
+	}
+	g.Expect(sources).To(HaveLen(1))
+	g.Expect(sources[0]).To(HaveKey("serviceAccountToken"))
+
+	//older images get the token of a service account token secret
+	c := bindataConfig()
+	c.RBAC.LegacyTokenEnv = true
+	_, token, secret = render(c)
+	g.Expect(token).NotTo(HaveKey("value"))
+	ref, _, _ := unstructured.NestedString(token, "valueFrom", "secretKeyRef", "name")
+	g.Expect(ref).To(Equal("nuage-cni"))
+	g.Expect(secret).NotTo(BeNil())
+	g.Expect(secret.GetName()).To(Equal(ref))
+	g.Expect(secret.Object["type"]).To(Equal("kubernetes.io/service-account-token"))
+	g.Expect(secret.GetAnnotations()).To(HaveKeyWithValue("kubernetes.io/service-account.name", "nuage-cni"))
+}
+
+func TestRenderBindataKubeConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := bindataConfig()
+	c.K8SAPIServerURL = "https://10.96.0.1:443"
+	c.ClusterCA = "ca"
+	c.CNIConfig.KubeConfigPath = "/etc/nuage/kubeconfig"
+	d := MakeRenderData(c)
+	objs, err := RenderDir(bindataPath, &d)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var kubeconfig, hostKubeconfig, pluginConfig string
+	mounts := map[string]bool{}
+	var sync map[string]interface{}
+	var volumes []interface{}
+	for _, obj := range objs {
+		if obj.GetKind() == "ConfigMap" && obj.GetName() == "nuage-kubeconfig" {
+			kubeconfig, _, _ = unstructured.NestedString(obj.Object, "data", "kubeconfig")
+			hostKubeconfig, _, _ = unstructured.NestedString(obj.Object, "data", "host_kubeconfig")
+		}
+		if obj.GetKind() == "ConfigMap" && obj.GetName() == "nuage-cni-config-data" {
+			pluginConfig, _, _ = unstructured.NestedString(obj.Object, "data", "plugin_yaml_config")
+		}
+		if obj.GetKind() != "DaemonSet" {
+			continue
+		}
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		for _, container := range containers {
+			container := container.(map[string]interface{})
+			if container["name"] == "nuage-kubeconfig-sync" {
+				sync = container
+				volumes, _, _ = unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "volumes")
+			}
+			volumeMounts, _, _ := unstructured.NestedSlice(container, "volumeMounts")
+			for _, m := range volumeMounts {
+				m := m.(map[string]interface{})
+				if m["name"] == "nuage-kubeconfig" && m["mountPath"] == "/etc/nuage" {
+					mounts[obj.GetName()] = true
+				}
+			}
+		}
+	}
+
+	g.Expect(kubeconfig).To(ContainSubstring(`server: "https://10.96.0.1:443"`))
+	g.Expect(kubeconfig).To(ContainSubstring("certificate-authority-data: Y2E="))
+	g.Expect(kubeconfig).To(ContainSubstring("tokenFile: /var/run/secrets/nuage/token"))
+	g.Expect(mounts).To(Equal(map[string]bool{"nuage-monitor": true}))
+
+	//the cni plugin runs on the host and reads the kubeconfig and token
+	//that the sync container copies there
+	g.Expect(pluginConfig).To(ContainSubstring("kubeConfig: /usr/share/vsp-k8s/nuage-operator.kubeconfig"))
+	g.Expect(hostKubeconfig).To(ContainSubstring(`server: "https://10.96.0.1:443"`))
+	g.Expect(hostKubeconfig).To(ContainSubstring("tokenFile: /usr/share/vsp-k8s/nuage-operator.token"))
+	g.Expect(sync).NotTo(BeNil())
+	g.Expect(sync["volumeMounts"]).To(ContainElement(map[string]interface{}{
+		"mountPath": "/host/usr/share/vsp-k8s",
+		"name":      "vsp-k8s-dir",
+	}))
+	g.Expect(volumes).To(ContainElement(map[string]interface{}{
+		"name":     "vsp-k8s-dir",
+		"hostPath": map[string]interface{}{"path": "/usr/share/vsp-k8s", "type": "DirectoryOrCreate"},
+	}))
+}
+
+func TestRenderBindataProxy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := bindataConfig()
+	c.Proxy = operv1.ClusterProxyConfig{
+		HTTPProxy:          "http://proxy.example.com:3128",
+		HTTPSProxy:         "http://proxy.example.com:3128",
+		NoProxy:            "localhost,10.128.0.0/14",
+		TrustedCAConfigMap: "corporate-ca",
+	}
+	d := MakeRenderData(c)
+	objs, err := RenderDir(bindataPath, &d)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	envs := map[string]map[string]interface{}{}
+	mounts := map[string]bool{}
+	for _, obj := range objs {
+		if obj.GetKind() != "DaemonSet" {
+			continue
+		}
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		env, _, _ := unstructured.NestedSlice(containers[0].(map[string]interface{}), "env")
+		for _, e := range env {
+			e := e.(map[string]interface{})
+			if strings.HasSuffix(e["name"].(string), "_PROXY") {
+				if envs[obj.GetName()] == nil {
+					envs[obj.GetName()] = map[string]interface{}{}
+				}
+				envs[obj.GetName()][e["name"].(string)] = e["value"]
+			}
+		}
+		volumeMounts, _, _ := unstructured.NestedSlice(containers[0].(map[string]interface{}), "volumeMounts")
+		for _, m := range volumeMounts {
+			m := m.(map[string]interface{})
+			if m["name"] == "trusted-ca" && m["mountPath"] == "/etc/pki/ca-trust/extracted/pem" {
+				mounts[obj.GetName()] = true
+			}
+		}
+	}
+
+	proxyEnv := map[string]interface{}{
+		"HTTP_PROXY":  "http://proxy.example.com:3128",
+		"HTTPS_PROXY": "http://proxy.example.com:3128",
+		"NO_PROXY":    "localhost,10.128.0.0/14",
+	}
+	g.Expect(envs).To(Equal(map[string]map[string]interface{}{"nuage-monitor": proxyEnv, "nuage-infra": proxyEnv}))
+	g.Expect(mounts).To(Equal(map[string]bool{"nuage-monitor": true, "nuage-infra": true}))
+
+	//nothing is rendered without a proxy
+	c.Proxy = operv1.ClusterProxyConfig{}
+	d = MakeRenderData(c)
+	objs, err = RenderDir(bindataPath, &d)
+	g.Expect(err).NotTo(HaveOccurred())
+	for _, obj := range objs {
+		data, err := obj.MarshalJSON()
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(data)).NotTo(ContainSubstring("_PROXY"))
+		g.Expect(string(data)).NotTo(ContainSubstring("trusted-ca"))
+	}
+}
+
+func TestRenderBindataVSDCA(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	render := func(c *operv1.RenderConfig) (map[string]string, *appsv1.DaemonSet) {
+		d := MakeRenderData(c)
+		objs, err := RenderDir(bindataPath, &d)
+		g.Expect(err).NotTo(HaveOccurred())
+		var data map[string]string
+		ds := &appsv1.DaemonSet{}
+		for _, obj := range objs {
+			if obj.GetKind() == "ConfigMap" && obj.GetName() == "nuage-monitor-config-data" {
+				data, _, _ = unstructured.NestedStringMap(obj.Object, "data")
+			}
+			if obj.GetKind() == "DaemonSet" && obj.GetName() == "nuage-monitor" {
+				g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, ds)).To(Succeed())
+			}
+		}
+		return data, ds
+	}
+	mounts := func(ds *appsv1.DaemonSet) map[string]string {
+		m := map[string]string{}
+		for _, v := range ds.Spec.Template.Spec.Containers[0].VolumeMounts {
+			m[v.Name] = v.MountPath
+		}
+		return m
+	}
+
+	c := bindataConfig()
+	data, ds := render(c)
+	g.Expect(data).NotTo(HaveKey("vsd_ca"))
+	g.Expect(mounts(ds)).NotTo(HaveKey("vsd-ca"))
+
+	//the ca is added to the system cas of the monitor
+	c.VSDCA = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	data, ds = render(c)
+	g.Expect(data).To(HaveKeyWithValue("vsd_ca", c.VSDCA))
+	g.Expect(data["monitor_yaml_config"]).NotTo(ContainSubstring("MIIB"))
+	g.Expect(mounts(ds)).To(HaveKeyWithValue("vsd-ca", "/etc/ssl/certs/nuage-vsd-ca.crt"))
+}
+
+func TestRenderBindataVSDEndpoints(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := bindataConfig()
+	c.MonitorConfig.VSDAddress = "vsd1.example.com"
+	c.MonitorConfig.VSDPort = 7443
+	c.MonitorConfig.VSDEndpoints = []operv1.VSDEndpoint{
+		{Address: "vsd1.example.com", Port: 7443},
+		{Address: "10.0.0.2", Port: 7443},
+		{Address: "fd00::3", Port: 8443},
+	}
+	d := MakeRenderData(c)
+	objs, err := RenderDir(bindataPath, &d)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	config := map[string]interface{}{}
+	for _, obj := range objs {
+		if obj.GetKind() == "ConfigMap" && obj.GetName() == "nuage-monitor-config-data" {
+			data, _, _ := unstructured.NestedString(obj.Object, "data", "monitor_yaml_config")
+			g.Expect(yaml.Unmarshal([]byte(data), &config)).To(Succeed())
+		}
+	}
+	g.Expect(config).To(HaveKeyWithValue("vsdApiUrl", "https://vsd1.example.com:7443"))
+	g.Expect(config).NotTo(HaveKey("vsdApiUrls"))
+}
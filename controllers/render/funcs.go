@@ -5,6 +5,8 @@
 package render
 
 import (
+	"net"
+	"strconv"
 	"strings"
 )
 
@@ -47,3 +49,9 @@ func boolToInt(b bool) int {
 func addEscapeChar(s string) string {
 	return strings.Replace(s, "/", "\\\\/", -1)
 }
+
+// hostPort joins host and port into an address, enclosing ipv6 hosts in
+// square brackets
+func hostPort(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
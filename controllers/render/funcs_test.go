@@ -22,3 +22,10 @@ func TestAddEscapeChar(t *testing.T) {
 	got = addEscapeChar(orig)
 	g.Expect(exp).To(Equal(got))
 }
+
+func TestHostPort(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(hostPort("10.0.0.1", 7443)).To(Equal("10.0.0.1:7443"))
+	g.Expect(hostPort("vsd.example.com", 7443)).To(Equal("vsd.example.com:7443"))
+	g.Expect(hostPort("fd00::1", 7443)).To(Equal("[fd00::1]:7443"))
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package render
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/cni"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/infra"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/monitor"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/vrs"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files under testdata")
+
+// goldenConfig returns a render config with the defaults filled in by the
+// component parsers, as the controller renders it
+func goldenConfig(g *GomegaWithT, orchestrator string) *operv1.RenderConfig {
+	c := bindataConfig()
+	c.Orchestrator = orchestrator
+	c.K8SAPIServerURL = "https://192.168.1.10:6443"
+	c.ClusterCA = "cluster-ca"
+	c.ReleaseConfig = operv1.ReleaseConfigDefinition{
+		VRSTag:     "registry.domain.tld/nuage/vrs:20.10.2",
+		CNITag:     "registry.domain.tld/nuage/cni:20.10.2",
+		MonitorTag: "registry.domain.tld/nuage/monitor:20.10.2",
+		InfraTag:   "registry.domain.tld/nuage/infra:20.10.2",
+	}
+	c.VRSConfig.UnderlayUplink = "eth0"
+	c.VRSConfig.Platform = "kvm, k8s"
+	c.CNIConfig.LoadBalancerURL = "https://192.168.1.10:9443"
+	c.MonitorConfig = operv1.MonitorConfigDefinition{
+		VSDAddress: "10.0.0.1",
+		VSDPort:    7443,
+		VSDMetadata: operv1.Metadata{
+			Enterprise: "enterprise",
+			Domain:     "domain",
+			User:       "admin",
+			UserCert:   "user-cert",
+			UserKey:    "user-key",
+		},
+	}
+
+	if orchestrator == "ose" {
+		c.MonitorConfig.Etcd.ClientCertSecret = &corev1.LocalObjectReference{Name: "etcd-client"}
+		g.Expect(monitor.ValidateOpenShift(&c.MonitorConfig)).To(Succeed())
+	}
+
+	g.Expect(monitor.Parse(&c.MonitorConfig)).To(Succeed())
+	g.Expect(cni.Parse(&c.CNIConfig, &c.MonitorConfig)).To(Succeed())
+	g.Expect(vrs.Parse(&c.VRSConfig)).To(Succeed())
+	g.Expect(infra.Parse(&c.InfraConfig, &c.MonitorConfig.VSDMetadata)).To(Succeed())
+	c.VRSConfig = vrs.RenderConfig(c.VRSConfig)
+	c.MonitorConfig = monitor.RenderConfig(c.MonitorConfig)
+	return c
+}
+
+func TestRenderBindataGolden(t *testing.T) {
+	for _, orchestrator := range []string{"k8s", "ose"} {
+		t.Run(orchestrator, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			d := MakeRenderData(goldenConfig(g, orchestrator))
+			objs, err := RenderDir(bindataPath, &d)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			var buf bytes.Buffer
+			for _, obj := range objs {
+				data, err := yaml.Marshal(obj.Object)
+				g.Expect(err).NotTo(HaveOccurred())
+				buf.WriteString("---\n")
+				buf.Write(data)
+			}
+
+			golden := filepath.Join("testdata", "bindata-"+orchestrator+".golden")
+			if *updateGolden {
+				g.Expect(ioutil.WriteFile(golden, buf.Bytes(), 0644)).To(Succeed())
+			}
+			expected, err := ioutil.ReadFile(golden)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(buf.String()).To(Equal(string(expected)), "rendered manifests differ from %s, rerun with -update if intended", golden)
+		})
+	}
+}
@@ -74,7 +74,7 @@ func RenderTemplate(path string, d *RenderData) ([]*unstructured.Unstructured, e
 	}
 
 	// Add universal functions
-	tmpl.Funcs(template.FuncMap{"getOr": getOr, "isSet": isSet, "boolToInt": boolToInt, "addEscapeChar": addEscapeChar})
+	tmpl.Funcs(template.FuncMap{"getOr": getOr, "isSet": isSet, "boolToInt": boolToInt, "addEscapeChar": addEscapeChar, "hostPort": hostPort})
 	tmpl.Funcs(sprig.TxtFuncMap())
 
 	source, err := ioutil.ReadFile(path)
@@ -4,14 +4,6 @@
 
 package controllers
 
-import (
-	"context"
-	"fmt"
-
-	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-)
-
 ////CreateServiceAccount creates a service account
 //func (r *ReconcileNuageCNIConfig) CreateServiceAccount(name, namespace string) error {
 //	sa := &corev1.ServiceAccount{
@@ -47,33 +39,6 @@ import (
 //	return sa, nil
 //}
 
-//GetSecret fetches secret from api server
-func (r *NuageCNIConfigReconciler) GetSecret(saname, namespace string) (*corev1.Secret, error) {
-
-	secList, err := r.clientset.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		return nil, err
-	}
-
-	for _, sec := range secList.Items {
-		if sec.ObjectMeta.Annotations["kubernetes.io/service-account.name"] == saname {
-			return &sec, nil
-		}
-	}
-
-	return nil, fmt.Errorf("could not find the secret")
-}
-
-//ExtractSecretToken extract the token from the secret
-func (r *NuageCNIConfigReconciler) ExtractSecretToken(s *corev1.Secret) ([]byte, error) {
-	token, ok := s.Data[corev1.ServiceAccountTokenKey]
-	if !ok {
-		return []byte{}, fmt.Errorf("could find key %s in secret %v", corev1.ServiceAccountTokenKey, s)
-	}
-
-	return token, nil
-}
-
 ////ExtractSecrets extracts secret name from service account yaml
 //func (r *ReconcileNuageCNIConfig) ExtractSecrets(sa *corev1.ServiceAccount) []corev1.ObjectReference {
 //	return sa.Secrets
@@ -0,0 +1,99 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	//ReasonAsExpected is the reason used when a condition is in its expected state
+	ReasonAsExpected = "AsExpected"
+)
+
+// SetCondition adds the condition to the status or updates it if a condition
+// of the same type is already present. It returns true if the status changed
+func SetCondition(s *operv1.NuageCNIConfigStatus, t operv1.ConditionType, status corev1.ConditionStatus, reason, message string) bool {
+	for i := range s.Conditions {
+		c := &s.Conditions[i]
+		if c.Type != t {
+			continue
+		}
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return false
+		}
+		if c.Status != status {
+			c.LastTransitionTime = metav1.Now()
+		}
+		c.Status = status
+		c.Reason = reason
+		c.Message = message
+		return true
+	}
+
+	s.Conditions = append(s.Conditions, operv1.Condition{
+		Type:               t,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	return true
+}
+
+// GetCondition returns the condition of given type, nil if not present
+func GetCondition(s *operv1.NuageCNIConfigStatus, t operv1.ConditionType) *operv1.Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+//UpdateStatus writes the status of the instance to api server
+func (r *NuageCNIConfigReconciler) UpdateStatus(instance *operv1.NuageCNIConfig) error {
+	return r.Client.Status().Update(context.TODO(), instance)
+}
+
+//SetDegraded marks the instance as degraded and saves the status
+func (r *NuageCNIConfigReconciler) SetDegraded(instance *operv1.NuageCNIConfig, reason, message string) error {
+	if !SetCondition(&instance.Status, operv1.ConditionDegraded, corev1.ConditionTrue, reason, message) {
+		return nil
+	}
+	return r.UpdateStatus(instance)
+}
+
+//ClearDegraded marks the instance as not degraded and saves the status
+func (r *NuageCNIConfigReconciler) ClearDegraded(instance *operv1.NuageCNIConfig) error {
+	if !SetCondition(&instance.Status, operv1.ConditionDegraded, corev1.ConditionFalse, ReasonAsExpected, "") {
+		return nil
+	}
+	return r.UpdateStatus(instance)
+}
+
+//SetManagementState records the management state in use and saves the status
+func (r *NuageCNIConfigReconciler) SetManagementState(instance *operv1.NuageCNIConfig, state operv1.ManagementState) error {
+	if instance.Status.ManagementState == state {
+		return nil
+	}
+	instance.Status.ManagementState = state
+	return r.UpdateStatus(instance)
+}
+
+//SetMasterNodes records the nodes running the monitor and saves the status
+func (r *NuageCNIConfigReconciler) SetMasterNodes(instance *operv1.NuageCNIConfig, masters []string) error {
+	status := operv1.MasterNodesStatus{Count: len(masters), Nodes: masters}
+	if reflect.DeepEqual(instance.Status.MasterNodes, status) {
+		return nil
+	}
+	instance.Status.MasterNodes = status
+	return r.UpdateStatus(instance)
+}
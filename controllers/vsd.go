@@ -0,0 +1,250 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/network/monitor"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http/httpproxy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	//ReasonVSDCAUnavailable is reported when the configured VSD ca cannot be read
+	ReasonVSDCAUnavailable = "VSDCAUnavailable"
+	//ReasonVSDCertificateExpired is reported when the VSD certificate is expired or not yet valid
+	ReasonVSDCertificateExpired = "VSDCertificateExpired"
+	//ReasonVSDHostnameMismatch is reported when the VSD certificate does not cover the VSD address
+	ReasonVSDHostnameMismatch = "VSDHostnameMismatch"
+	//ReasonVSDUnknownAuthority is reported when the VSD certificate is not signed by the VSD ca
+	ReasonVSDUnknownAuthority = "VSDUnknownAuthority"
+	//ReasonVSDCertificateInvalid is reported for any other verification failure of the VSD certificate
+	ReasonVSDCertificateInvalid = "VSDCertificateInvalid"
+	//ReasonVSDUnreachable is reported when the VSD certificate could not be fetched
+	ReasonVSDUnreachable = "VSDUnreachable"
+
+	//VSDTimeout bounds every call the operator makes to VSD
+	VSDTimeout = 10 * time.Second
+	//VSDCheckInterval is how long the result of a VSD certificate check is
+	//kept before the VSD endpoints are contacted again
+	VSDCheckInterval = 5 * time.Minute
+
+	vsdCAKey = "ca.crt"
+)
+
+//GetVSDCA returns the pem encoded ca the VSD certificate is verified with.
+//It is empty when no ca is configured and the system cas are used
+func (r *NuageCNIConfigReconciler) GetVSDCA(config *operv1.VSDCADefinition) (string, error) {
+	var data map[string]string
+	switch {
+	case config.SecretRef != nil:
+		s, err := r.clientset.CoreV1().Secrets(names.Namespace).Get(context.TODO(), config.SecretRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		data = map[string]string{vsdCAKey: string(s.Data[vsdCAKey])}
+	case config.ConfigMapRef != nil:
+		cm, err := r.clientset.CoreV1().ConfigMaps(names.Namespace).Get(context.TODO(), config.ConfigMapRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		data = cm.Data
+	default:
+		return config.CA, nil
+	}
+
+	if len(data[vsdCAKey]) == 0 {
+		return "", fmt.Errorf("vsd ca has no %s", vsdCAKey)
+	}
+	return data[vsdCAKey], nil
+}
+
+// vsdCheck is the last VSD certificate check and the config it was made with
+type vsdCheck struct {
+	config string
+	time   time.Time
+}
+
+//NewVSDClient returns the http client for every call the operator makes to
+//VSD. The VSD certificate is verified with the given ca, or the system cas
+//when it is empty. VSD is reached through the computed proxy config, the
+//environment of the operator does not carry spec.proxy on kubernetes
+func NewVSDClient(ca string, proxy operv1.ClusterProxyConfig) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	if len(ca) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(ca)) {
+			return nil, fmt.Errorf("vsd ca contains no valid certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Timeout: VSDTimeout,
+		Transport: &http.Transport{
+			Proxy:               vsdProxy(proxy),
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: VSDTimeout,
+		},
+	}, nil
+}
+
+// vsdProxy returns the proxy of a request to VSD from the proxy config
+func vsdProxy(proxy operv1.ClusterProxyConfig) func(*http.Request) (*url.URL, error) {
+	proxyURL := (&httpproxy.Config{
+		HTTPProxy:  proxy.HTTPProxy,
+		HTTPSProxy: proxy.HTTPSProxy,
+		NoProxy:    proxy.NoProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyURL(req.URL)
+	}
+}
+
+// vsdURL returns the url of the VSD api
+func vsdURL(endpoint operv1.VSDEndpoint) string {
+	return "https://" + net.JoinHostPort(endpoint.Address, strconv.Itoa(endpoint.Port))
+}
+
+//VSDCertificateStatus connects to a VSD endpoint and returns the status,
+//reason and message of its certificate verification
+func VSDCertificateStatus(endpoint operv1.VSDEndpoint, ca string, proxy operv1.ClusterProxyConfig) (corev1.ConditionStatus, string, string) {
+	client, err := NewVSDClient(ca, proxy)
+	if err != nil {
+		return corev1.ConditionFalse, ReasonVSDCAUnavailable, err.Error()
+	}
+
+	url := vsdURL(endpoint)
+	resp, err := client.Head(url)
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+			return corev1.ConditionUnknown, ReasonVSDUnreachable, fmt.Sprintf("%s presented no certificate", url)
+		}
+		return corev1.ConditionTrue, ReasonAsExpected,
+			fmt.Sprintf("certificate of %s is valid until %s", url, resp.TLS.PeerCertificates[0].NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var authority x509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return corev1.ConditionFalse, ReasonVSDCertificateExpired, invalid.Error()
+	case errors.As(err, &invalid):
+		return corev1.ConditionFalse, ReasonVSDCertificateInvalid, invalid.Error()
+	case errors.As(err, &hostname):
+		return corev1.ConditionFalse, ReasonVSDHostnameMismatch, hostname.Error()
+	case errors.As(err, &authority):
+		return corev1.ConditionFalse, ReasonVSDUnknownAuthority, authority.Error()
+	}
+	return corev1.ConditionUnknown, ReasonVSDUnreachable, err.Error()
+}
+
+//VSDEndpointsStatus checks every VSD endpoint concurrently and returns
+//their health in order
+func VSDEndpointsStatus(endpoints []operv1.VSDEndpoint, ca string, proxy operv1.ClusterProxyConfig) []operv1.VSDEndpointStatus {
+	statuses := make([]operv1.VSDEndpointStatus, len(endpoints))
+	var wg sync.WaitGroup
+	for i := range endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status, reason, message := VSDCertificateStatus(endpoints[i], ca, proxy)
+			statuses[i] = operv1.VSDEndpointStatus{VSDEndpoint: endpoints[i], Healthy: status == corev1.ConditionTrue, Message: message}
+			if !statuses[i].Healthy {
+				statuses[i].Reason = reason
+			}
+		}(i)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// vsdCertificateCondition folds the endpoint health into the
+// VSDCertificateValid condition. A certificate problem on any endpoint
+// makes it false, it is unknown when no endpoint could be reached
+func vsdCertificateCondition(statuses []operv1.VSDEndpointStatus) (corev1.ConditionStatus, string, string) {
+	var valid []string
+	for _, s := range statuses {
+		if s.Healthy {
+			valid = append(valid, s.Message)
+			continue
+		}
+		if s.Reason != ReasonVSDUnreachable {
+			return corev1.ConditionFalse, s.Reason, s.Message
+		}
+	}
+	if len(valid) == 0 {
+		return corev1.ConditionUnknown, ReasonVSDUnreachable, statuses[0].Message
+	}
+	return corev1.ConditionTrue, ReasonAsExpected, strings.Join(valid, "; ")
+}
+
+// activeVSDEndpoint returns the endpoint the monitor is configured with, the
+// first one recorded healthy. It is the first endpoint when none is healthy
+func activeVSDEndpoint(endpoints []operv1.VSDEndpoint, statuses []operv1.VSDEndpointStatus) operv1.VSDEndpoint {
+	for _, e := range endpoints {
+		for _, s := range statuses {
+			if s.VSDEndpoint == e && s.Healthy {
+				return e
+			}
+		}
+	}
+	return endpoints[0]
+}
+
+//CheckVSDCertificate records the health of every VSD endpoint and the
+//result of verifying their certificates in the VSDCertificateValid
+//condition. An invalid certificate does not stop the reconcile, the monitor
+//reports its own connection errors. The endpoints are contacted again once
+//VSDCheckInterval passed or the endpoints, ca or proxy changed, an
+//unreachable VSD would otherwise hold every reconcile for the timeout
+func (r *NuageCNIConfigReconciler) CheckVSDCertificate(instance *operv1.NuageCNIConfig, ca string, proxy operv1.ClusterProxyConfig) error {
+	endpoints := monitor.VSDEndpoints(&instance.Spec.MonitorConfig)
+	config := fmt.Sprintf("%v %q %v", endpoints, ca, proxy)
+	if r.vsdCheck.config == config && time.Since(r.vsdCheck.time) < VSDCheckInterval &&
+		GetCondition(&instance.Status, operv1.ConditionVSDCertificateValid) != nil {
+		return nil
+	}
+
+	statuses := VSDEndpointsStatus(endpoints, ca, proxy)
+	for _, s := range statuses {
+		if !s.Healthy {
+			log.Errorf("vsd endpoint %s is not healthy %s: %s", vsdURL(s.VSDEndpoint), s.Reason, s.Message)
+		}
+	}
+
+	status, reason, message := vsdCertificateCondition(statuses)
+	changed := SetCondition(&instance.Status, operv1.ConditionVSDCertificateValid, status, reason, message)
+	if !reflect.DeepEqual(instance.Status.VSDEndpoints, statuses) {
+		instance.Status.VSDEndpoints = statuses
+		changed = true
+	}
+	if changed {
+		if err := r.UpdateStatus(instance); err != nil {
+			return err
+		}
+	}
+	r.vsdCheck = vsdCheck{config: config, time: time.Now()}
+	return nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2020 Nokia
+// Licensed under the Apache License 2.0.
+// SPDX-License-Identifier: Apache-2.0
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	operv1 "github.com/nuagenetworks/nuage-network-operator/api/v1alpha1"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/certs"
+	"github.com/nuagenetworks/nuage-network-operator/controllers/names"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// startVSD serves https with a certificate for 127.0.0.1 and returns the
+// server, its endpoint and the ca of the certificate
+func startVSD(g *GomegaWithT, config *operv1.CertGenConfig) (*httptest.Server, operv1.VSDEndpoint, string) {
+	config.Hosts = []string{"127.0.0.1"}
+	c, err := certs.GenerateCertificates(config)
+	g.Expect(err).NotTo(HaveOccurred())
+	cert, err := tls.X509KeyPair([]byte(*c.Certificate), []byte(*c.PrivateKey))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	g.Expect(err).NotTo(HaveOccurred())
+	p, err := strconv.Atoi(port)
+	g.Expect(err).NotTo(HaveOccurred())
+	return server, operv1.VSDEndpoint{Address: host, Port: p}, *c.CA
+}
+
+func TestVSDCertificateStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+	server, endpoint, ca := startVSD(g, &operv1.CertGenConfig{})
+	defer server.Close()
+
+	status, reason, message := VSDCertificateStatus(endpoint, ca, operv1.ClusterProxyConfig{})
+	g.Expect(status).To(Equal(corev1.ConditionTrue))
+	g.Expect(reason).To(Equal(ReasonAsExpected))
+	g.Expect(message).To(ContainSubstring("is valid until"))
+
+	//the system cas do not know the operator generated ca
+	status, reason, _ = VSDCertificateStatus(endpoint, "", operv1.ClusterProxyConfig{})
+	g.Expect(status).To(Equal(corev1.ConditionFalse))
+	g.Expect(reason).To(Equal(ReasonVSDUnknownAuthority))
+
+	//the certificate only covers 127.0.0.1
+	localhost := endpoint
+	localhost.Address = "localhost"
+	status, reason, _ = VSDCertificateStatus(localhost, ca, operv1.ClusterProxyConfig{})
+	g.Expect(status).To(Equal(corev1.ConditionFalse))
+	g.Expect(reason).To(Equal(ReasonVSDHostnameMismatch))
+
+	status, reason, _ = VSDCertificateStatus(endpoint, "not a certificate", operv1.ClusterProxyConfig{})
+	g.Expect(status).To(Equal(corev1.ConditionFalse))
+	g.Expect(reason).To(Equal(ReasonVSDCAUnavailable))
+
+	validFrom := time.Now().Add(-48 * time.Hour).UTC().Format("Jan 2 15:04:05 2006")
+	expiredServer, expired, expiredCA := startVSD(g, &operv1.CertGenConfig{ValidFrom: &validFrom, ValidFor: 24 * time.Hour})
+	defer expiredServer.Close()
+	status, reason, _ = VSDCertificateStatus(expired, expiredCA, operv1.ClusterProxyConfig{})
+	g.Expect(status).To(Equal(corev1.ConditionFalse))
+	g.Expect(reason).To(Equal(ReasonVSDCertificateExpired))
+
+	status, reason, _ = VSDCertificateStatus(closedEndpoint(g), ca, operv1.ClusterProxyConfig{})
+	g.Expect(status).To(Equal(corev1.ConditionUnknown))
+	g.Expect(reason).To(Equal(ReasonVSDUnreachable))
+}
+
+// closedEndpoint returns an endpoint nothing listens on
+func closedEndpoint(g *GomegaWithT) operv1.VSDEndpoint {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer l.Close()
+	return operv1.VSDEndpoint{Address: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port}
+}
+
+func TestCheckVSDCertificate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server, endpoint, ca := startVSD(g, &operv1.CertGenConfig{})
+	defer server.Close()
+	down := closedEndpoint(g)
+
+	s := runtime.NewScheme()
+	g.Expect(operv1.AddToScheme(s)).To(Succeed())
+
+	instance := &operv1.NuageCNIConfig{ObjectMeta: metav1.ObjectMeta{Name: "nuage"}}
+	instance.Spec.MonitorConfig.VSDEndpoints = []operv1.VSDEndpoint{down, endpoint}
+	r := &NuageCNIConfigReconciler{Client: fake.NewFakeClientWithScheme(s, instance)}
+
+	//every endpoint is checked and recorded
+	g.Expect(r.CheckVSDCertificate(instance, ca, operv1.ClusterProxyConfig{})).To(Succeed())
+	g.Expect(instance.Status.VSDEndpoints).To(HaveLen(2))
+	g.Expect(instance.Status.VSDEndpoints[0].VSDEndpoint).To(Equal(down))
+	g.Expect(instance.Status.VSDEndpoints[0].Healthy).To(BeFalse())
+	g.Expect(instance.Status.VSDEndpoints[0].Reason).To(Equal(ReasonVSDUnreachable))
+	g.Expect(instance.Status.VSDEndpoints[1].VSDEndpoint).To(Equal(endpoint))
+	g.Expect(instance.Status.VSDEndpoints[1].Healthy).To(BeTrue())
+	g.Expect(instance.Status.VSDEndpoints[1].Reason).To(BeEmpty())
+	c := GetCondition(&instance.Status, operv1.ConditionVSDCertificateValid)
+	g.Expect(c).NotTo(BeNil())
+	g.Expect(c.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(c.Message).To(ContainSubstring("is valid until"))
+
+	saved := &operv1.NuageCNIConfig{}
+	g.Expect(r.Client.Get(context.TODO(), types.NamespacedName{Name: "nuage"}, saved)).To(Succeed())
+	g.Expect(saved.Status.VSDEndpoints).To(Equal(instance.Status.VSDEndpoints))
+
+	//a certificate problem on any endpoint is reported
+	r.vsdCheck = vsdCheck{}
+	g.Expect(r.CheckVSDCertificate(instance, "", operv1.ClusterProxyConfig{})).To(Succeed())
+	c = GetCondition(&instance.Status, operv1.ConditionVSDCertificateValid)
+	g.Expect(c.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(c.Reason).To(Equal(ReasonVSDUnknownAuthority))
+
+	instance.Spec.MonitorConfig.VSDEndpoints = []operv1.VSDEndpoint{down}
+	r.vsdCheck = vsdCheck{}
+	g.Expect(r.CheckVSDCertificate(instance, ca, operv1.ClusterProxyConfig{})).To(Succeed())
+	c = GetCondition(&instance.Status, operv1.ConditionVSDCertificateValid)
+	g.Expect(c.Status).To(Equal(corev1.ConditionUnknown))
+	g.Expect(c.Reason).To(Equal(ReasonVSDUnreachable))
+	g.Expect(instance.Status.VSDEndpoints).To(HaveLen(1))
+
+	//without endpoints the single vsd address is checked
+	instance.Spec.MonitorConfig.VSDEndpoints = nil
+	instance.Spec.MonitorConfig.VSDAddress = endpoint.Address
+	instance.Spec.MonitorConfig.VSDPort = endpoint.Port
+	r.vsdCheck = vsdCheck{}
+	g.Expect(r.CheckVSDCertificate(instance, ca, operv1.ClusterProxyConfig{})).To(Succeed())
+	g.Expect(instance.Status.VSDEndpoints).To(HaveLen(1))
+	g.Expect(instance.Status.VSDEndpoints[0].Healthy).To(BeTrue())
+}
+
+func TestCheckVSDCertificateInterval(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server, endpoint, ca := startVSD(g, &operv1.CertGenConfig{})
+	down := closedEndpoint(g)
+
+	s := runtime.NewScheme()
+	g.Expect(operv1.AddToScheme(s)).To(Succeed())
+
+	instance := &operv1.NuageCNIConfig{ObjectMeta: metav1.ObjectMeta{Name: "nuage"}}
+	instance.Spec.MonitorConfig.VSDEndpoints = []operv1.VSDEndpoint{endpoint}
+	r := &NuageCNIConfigReconciler{Client: fake.NewFakeClientWithScheme(s, instance)}
+
+	g.Expect(r.CheckVSDCertificate(instance, ca, operv1.ClusterProxyConfig{})).To(Succeed())
+	g.Expect(instance.Status.VSDEndpoints[0].Healthy).To(BeTrue())
+
+	//the last result is kept until the interval passed
+	server.Close()
+	g.Expect(r.CheckVSDCertificate(instance, ca, operv1.ClusterProxyConfig{})).To(Succeed())
+	g.Expect(instance.Status.VSDEndpoints[0].Healthy).To(BeTrue())
+
+	r.vsdCheck.time = time.Now().Add(-VSDCheckInterval)
+	g.Expect(r.CheckVSDCertificate(instance, ca, operv1.ClusterProxyConfig{})).To(Succeed())
+	g.Expect(instance.Status.VSDEndpoints[0].Healthy).To(BeFalse())
+
+	//a changed config is checked right away
+	instance.Spec.MonitorConfig.VSDEndpoints = []operv1.VSDEndpoint{down}
+	g.Expect(r.CheckVSDCertificate(instance, ca, operv1.ClusterProxyConfig{})).To(Succeed())
+	g.Expect(instance.Status.VSDEndpoints[0].VSDEndpoint).To(Equal(down))
+}
+
+func TestVSDCertificateStatusProxy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	//the proxy refuses every connect, the request shows it was used
+	var connects []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connects = append(connects, r.Host)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	vsd := operv1.VSDEndpoint{Address: "vsd.example.com", Port: 8443}
+	config := operv1.ClusterProxyConfig{HTTPSProxy: proxy.URL}
+	status, reason, _ := VSDCertificateStatus(vsd, "", config)
+	g.Expect(status).To(Equal(corev1.ConditionUnknown))
+	g.Expect(reason).To(Equal(ReasonVSDUnreachable))
+	g.Expect(connects).To(Equal([]string{"vsd.example.com:8443"}))
+
+	//the no proxy list is honoured
+	server, endpoint, ca := startVSD(g, &operv1.CertGenConfig{})
+	defer server.Close()
+	connects = nil
+	config.NoProxy = endpoint.Address
+	status, _, _ = VSDCertificateStatus(endpoint, ca, config)
+	g.Expect(status).To(Equal(corev1.ConditionTrue))
+	g.Expect(connects).To(BeEmpty())
+}
+
+func TestActiveVSDEndpoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	vsd1 := operv1.VSDEndpoint{Address: "vsd1", Port: 8443}
+	vsd2 := operv1.VSDEndpoint{Address: "vsd2", Port: 8443}
+	vsd3 := operv1.VSDEndpoint{Address: "vsd3", Port: 8443}
+	endpoints := []operv1.VSDEndpoint{vsd1, vsd2, vsd3}
+
+	g.Expect(activeVSDEndpoint(endpoints, nil)).To(Equal(vsd1))
+	g.Expect(activeVSDEndpoint(endpoints, []operv1.VSDEndpointStatus{
+		{VSDEndpoint: vsd1, Healthy: false},
+		{VSDEndpoint: vsd2, Healthy: false},
+		{VSDEndpoint: vsd3, Healthy: true},
+	})).To(Equal(vsd3))
+	g.Expect(activeVSDEndpoint(endpoints, []operv1.VSDEndpointStatus{
+		{VSDEndpoint: vsd1, Healthy: true},
+		{VSDEndpoint: vsd2, Healthy: true},
+	})).To(Equal(vsd1))
+	g.Expect(activeVSDEndpoint(endpoints, []operv1.VSDEndpointStatus{
+		{VSDEndpoint: vsd1, Healthy: false},
+		{VSDEndpoint: vsd2, Healthy: false},
+	})).To(Equal(vsd1))
+
+	//the status of removed endpoints is not used
+	g.Expect(activeVSDEndpoint([]operv1.VSDEndpoint{vsd1, vsd2}, []operv1.VSDEndpointStatus{
+		{VSDEndpoint: vsd3, Healthy: true},
+		{VSDEndpoint: vsd2, Healthy: true},
+	})).To(Equal(vsd2))
+}
+
+func TestGetVSDCA(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	r := &NuageCNIConfigReconciler{
+		clientset: kubefake.NewSimpleClientset(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "vsd-ca", Namespace: names.Namespace},
+				Data:       map[string][]byte{"ca.crt": []byte("secret ca")},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "vsd-ca", Namespace: names.Namespace},
+				Data:       map[string]string{"ca.crt": "configmap ca"},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: names.Namespace},
+			},
+		),
+	}
+
+	ca, err := r.GetVSDCA(&operv1.VSDCADefinition{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(BeEmpty())
+
+	ca, err = r.GetVSDCA(&operv1.VSDCADefinition{CA: "inline ca"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(Equal("inline ca"))
+
+	ca, err = r.GetVSDCA(&operv1.VSDCADefinition{SecretRef: &corev1.LocalObjectReference{Name: "vsd-ca"}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(Equal("secret ca"))
+
+	ca, err = r.GetVSDCA(&operv1.VSDCADefinition{ConfigMapRef: &corev1.LocalObjectReference{Name: "vsd-ca"}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ca).To(Equal("configmap ca"))
+
+	_, err = r.GetVSDCA(&operv1.VSDCADefinition{ConfigMapRef: &corev1.LocalObjectReference{Name: "empty"}})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("vsd ca has no ca.crt"))
+
+	_, err = r.GetVSDCA(&operv1.VSDCADefinition{SecretRef: &corev1.LocalObjectReference{Name: "missing"}})
+	g.Expect(err).To(HaveOccurred())
+}
@@ -5,5 +5,5 @@
 package version
 
 var (
-	Version = "0.0.1"
+	Version = "0.1.0"
 )